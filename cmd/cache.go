@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/gork74/aws-parameter-bulk/pkg/util"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+func init() { // nolint: gochecknoinits
+	cacheCmd := &cobra.Command{
+		Use:   "cache",
+		Short: "cache clear",
+		Long: "cache clear\n\n" +
+			"The cache stores the results of reading parameters encrypted on disk, see the config file settings cache.*",
+	}
+	clearCmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "clear",
+		Short: "Remove all cached results",
+		Run: func(cmd *cobra.Command, args []string) {
+			dir := cacheDir()
+			log.Debug().Msgf("Cache: %s", dir)
+			if err := util.ClearCache(dir); err != nil {
+				log.Error().Msg(err.Error())
+				os.Exit(1)
+				return
+			}
+			fmt.Printf("Cleared cache: %s\n", dir)
+		},
+	}
+	cacheCmd.AddCommand(clearCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
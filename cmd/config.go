@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gork74/aws-parameter-bulk/conf"
+	"github.com/gork74/aws-parameter-bulk/pkg/util"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+// newSSM creates a client for the session options, with the settings of the config file
+func newSSM(options util.SessionOptions) *util.AWSSSM {
+	ssmClient := util.NewSSMWithOptions(options)
+	var kmsKeys []util.KmsKeyMapping
+	if err := viper.UnmarshalKey("kms", &kmsKeys); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read kms mapping from config file: %s\n", err.Error())
+		os.Exit(1)
+	}
+	ssmClient.KmsKeys = kmsKeys
+	ssmClient.Retry.MaxAttempts = viper.GetInt("retry.maxattempts")
+	if ssmClient.Retry.MaxAttempts < 1 {
+		fmt.Fprintf(os.Stderr, "Max attempts has to be at least 1: %d\n", ssmClient.Retry.MaxAttempts)
+		os.Exit(1)
+	}
+	if viper.GetBool("cache.enabled") {
+		ssmClient.Cache = newCache()
+	}
+	return ssmClient
+}
+
+// cacheDir is the directory of the cache, by default in the user cache directory
+func cacheDir() string {
+	if dir := viper.GetString("cache.dir"); dir != "" {
+		return dir
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, conf.Executable)
+}
+
+// newCache creates the cache with the key from a passphrase file or the OS keyring.
+// The cache is only an optimization, so without a key it is disabled with a warning.
+func newCache() *util.Cache {
+	dir := cacheDir()
+	var key []byte
+	var err error
+	if passphraseFile := viper.GetString("cache.passphrasefile"); passphraseFile != "" {
+		key, err = util.CacheKeyFromPassphraseFile(passphraseFile, dir)
+	} else {
+		key, err = util.CacheKeyFromKeyring(conf.Executable)
+	}
+	if err != nil {
+		log.Warn().Msgf("Cache disabled, no key: %s", err.Error())
+		return nil
+	}
+	cache, err := util.NewCache(dir, viper.GetDuration("cache.ttl"), key)
+	if err != nil {
+		log.Warn().Msgf("Cache disabled: %s", err.Error())
+		return nil
+	}
+	cache.Refresh = viper.GetBool("cache.refresh")
+	return cache
+}
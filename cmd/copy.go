@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"github.com/gork74/aws-parameter-bulk/pkg/util"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+func init() { // nolint: gochecknoinits
+	copyCmd := &cobra.Command{
+		Args:  cobra.ExactArgs(2),
+		Use:   "copy [sourcepath] [targetpath]",
+		Short: "copy /staging/app /prod/app",
+		Long: "copy /staging/app /prod/app\n" +
+			"copy /dev/app /prod/app --source-profile dev --target-profile prod\n\n" +
+			"Copies all parameters under the source path to the same names under the target path.\n" +
+			"Keeps the type, kms key, description and tags of each parameter.\n" +
+			"Source and target can use different profiles and regions, to copy across accounts and regions.\n" +
+			"Use --help for help on the flags: --dry --no-overwrite --recursive --source-profile --source-region " +
+			"--target-profile --target-region --target-kms-key-id",
+		Run: func(cmd *cobra.Command, args []string) {
+			dryFlag, _ := cmd.Flags().GetBool("dry")
+			noOverwriteFlag, _ := cmd.Flags().GetBool("no-overwrite")
+			recursiveFlag, _ := cmd.Flags().GetBool("recursive")
+			sourceProfile, _ := cmd.Flags().GetString("source-profile")
+			sourceRegion, _ := cmd.Flags().GetString("source-region")
+			targetProfile, _ := cmd.Flags().GetString("target-profile")
+			targetRegion, _ := cmd.Flags().GetString("target-region")
+			targetKeyId, _ := cmd.Flags().GetString("target-kms-key-id")
+			options := util.CopyOptions{
+				Recursive: recursiveFlag,
+				Overwrite: !noOverwriteFlag,
+				Dry:       dryFlag,
+				KeyId:     targetKeyId,
+			}
+			sourceOptions := util.SessionOptions{
+				Profile: sourceProfile,
+				Region:  sourceRegion,
+			}
+			targetOptions := util.SessionOptions{
+				Profile: targetProfile,
+				Region:  targetRegion,
+			}
+			log.Debug().Msgf("Source: %s %+v Target: %s %+v", args[0], sourceOptions, args[1], targetOptions)
+			log.Debug().Msgf("Options: %+v", options)
+
+			sourceClient := newSSM(sourceOptions)
+			targetClient := sourceClient
+			if targetOptions != sourceOptions {
+				targetClient = newSSM(targetOptions)
+			}
+			err := sourceClient.CopyParameters(targetClient, args[0], args[1], options)
+			if err != nil {
+				log.Error().Msg(err.Error())
+				os.Exit(1)
+				return
+			}
+		},
+	}
+	copyCmd.PersistentFlags().Bool("dry", false, "Dry run, just output what would be copied and do nothing.")
+	copyCmd.PersistentFlags().Bool("no-overwrite", false, "Do not overwrite parameters which already exist on the target path")
+	copyCmd.PersistentFlags().Bool("recursive", false, "Copy all parameters below the source path, not only the direct children")
+	copyCmd.PersistentFlags().String("source-profile", "", "AWS profile to read from, defaults to the current profile")
+	copyCmd.PersistentFlags().String("source-region", "", "AWS region to read from, defaults to the region of the source profile")
+	copyCmd.PersistentFlags().String("target-profile", "", "AWS profile to write to, defaults to the current profile")
+	copyCmd.PersistentFlags().String("target-region", "", "AWS region to write to, defaults to the region of the target profile")
+	copyCmd.PersistentFlags().String("target-kms-key-id", "", "KMS key for SecureStrings on the target, "+
+		"needed if the source key is not available in the target account or region")
+	rootCmd.AddCommand(copyCmd)
+}
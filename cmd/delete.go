@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/gork74/aws-parameter-bulk/pkg/util"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+func init() { // nolint: gochecknoinits
+	deleteCmd := &cobra.Command{
+		Args:  cobra.ExactArgs(1),
+		Use:   "delete [names]",
+		Short: "delete name1,/path1,/path2/subpath",
+		Long: "delete name1,/path1,/path2/subpath\n\n" +
+			"Accepts paths and non-path names, as a comma-separated list, like get.\n" +
+			"Lists all parameters which will be deleted and asks for a confirmation, unless --yes is given.\n" +
+			"Use --help for help on the flags: --dry --yes --norecursive",
+		Run: func(cmd *cobra.Command, args []string) {
+			dryFlag, _ := cmd.Flags().GetBool("dry")
+			yesFlag, _ := cmd.Flags().GetBool("yes")
+			noRecursiveFlag, _ := cmd.Flags().GetBool("norecursive")
+			flags := util.Flags{
+				Dry:       dryFlag,
+				Recursive: !noRecursiveFlag,
+			}
+			log.Debug().Msgf("Names/Paths: %s", args[0])
+			log.Debug().Msgf("Flags: %+v", flags)
+
+			// deleting always deletes all versions, a selected version or label would be misleading
+			for _, param := range util.SplitParams(&args[0]) {
+				if _, selector := util.SplitSelector(param); selector != "" {
+					log.Error().Msgf("Delete removes all versions, selectors can not be used: %s", param)
+					os.Exit(1)
+					return
+				}
+			}
+
+			ssmClient := newSSM(util.SessionOptions{})
+			names, err := ssmClient.ResolveParameterNames(&args[0], flags)
+			if err != nil {
+				log.Error().Msg(err.Error())
+				os.Exit(1)
+				return
+			}
+
+			if dryFlag {
+				fmt.Println("### Dry run, not deleting, this would have been deleted:")
+			}
+			for _, name := range names {
+				fmt.Println(name)
+			}
+			if dryFlag {
+				return
+			}
+			if !yesFlag && !confirm(fmt.Sprintf("Delete %d parameter(s)?", len(names))) {
+				fmt.Println("Aborted, nothing deleted")
+				os.Exit(1)
+				return
+			}
+
+			err = ssmClient.DeleteParameters(names)
+			if err != nil {
+				log.Error().Msg(err.Error())
+				os.Exit(1)
+				return
+			}
+		},
+	}
+	deleteCmd.PersistentFlags().Bool("dry", false, "Dry run, just output what would be deleted and do nothing.")
+	deleteCmd.PersistentFlags().Bool("yes", false, "Do not ask for a confirmation before deleting")
+	deleteCmd.PersistentFlags().Bool("norecursive", false, "Do not delete recursively if deleting a path")
+	rootCmd.AddCommand(deleteCmd)
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/gork74/aws-parameter-bulk/pkg/util"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+func init() { // nolint: gochecknoinits
+	diffCmd := &cobra.Command{
+		Args:  cobra.ExactArgs(2),
+		Use:   "diff [names] [names]",
+		Short: "diff /dev/app /prod/app",
+		Long: "diff /dev/app /prod/app\n" +
+			"diff /dev/app,name1 /prod/app,name2\n\n" +
+			"Reads both sides like get and compares the values by name.\n" +
+			"Keys only on the right side are added, keys only on the left side are removed.\n" +
+			"Exits with code 0 if both sides are equal, 1 if they differ and 2 on errors.\n" +
+			"Use --help for help on the flags: --format --novalues --injson --upper --norecursive --concurrency",
+		Run: func(cmd *cobra.Command, args []string) {
+			inJsonFlag, _ := cmd.Flags().GetBool("injson")
+			upperFlag, _ := cmd.Flags().GetBool("upper")
+			noRecursiveFlag, _ := cmd.Flags().GetBool("norecursive")
+			noValuesFlag, _ := cmd.Flags().GetBool("novalues")
+			format, _ := cmd.Flags().GetString("format")
+			concurrencyFlag, _ := cmd.Flags().GetInt("concurrency")
+			flags := util.Flags{
+				InJson:      inJsonFlag,
+				Upper:       upperFlag,
+				Recursive:   !noRecursiveFlag,
+				Concurrency: concurrencyFlag,
+			}
+			log.Debug().Msgf("Left: %s Right: %s", args[0], args[1])
+			log.Debug().Msgf("Flags: %+v", flags)
+
+			ssmClient := newSSM(util.SessionOptions{})
+			left, err := ssmClient.GetParams(&args[0], flags)
+			if err != nil {
+				log.Error().Msg(err.Error())
+				os.Exit(2)
+				return
+			}
+			right, err := ssmClient.GetParams(&args[1], flags)
+			if err != nil {
+				log.Error().Msg(err.Error())
+				os.Exit(2)
+				return
+			}
+
+			entries := util.DiffParams(left, right)
+			output, err := util.GetDiffOutputString(entries, args[0], args[1], format, !noValuesFlag)
+			if err != nil {
+				log.Error().Msg(err.Error())
+				os.Exit(2)
+				return
+			}
+			fmt.Print(output)
+			if util.HasDrift(entries) {
+				os.Exit(1)
+			}
+		},
+	}
+	diffCmd.PersistentFlags().String("format", util.DiffFormatText, "Output format: text (unified), json or table")
+	diffCmd.PersistentFlags().Bool("novalues", false, "Mask the values in the output, only show which keys differ")
+	diffCmd.PersistentFlags().Bool("injson", false, "Parse input parameter values as json and compare each json value. Each has to be json.")
+	diffCmd.PersistentFlags().Bool("upper", false, "Make keys uppercase")
+	diffCmd.PersistentFlags().Int("concurrency", 1, "Read this many paths and chunks of 10 names at the same time")
+	diffCmd.PersistentFlags().Bool("norecursive", false, "Do not read recursively if getting a path")
+	rootCmd.AddCommand(diffCmd)
+}
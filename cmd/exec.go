@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/gork74/aws-parameter-bulk/pkg/util"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+func init() { // nolint: gochecknoinits
+	execCmd := &cobra.Command{
+		Args: func(cmd *cobra.Command, args []string) error {
+			if cmd.ArgsLenAtDash() != 1 || len(args) < 2 {
+				return errors.New("requires names and a command separated by --: exec [names] -- [command]")
+			}
+			return nil
+		},
+		Use:     "exec [names] -- [command]",
+		Aliases: []string{"run"},
+		Short:   "exec /dev/app -- ./server",
+		Long: "exec /dev/app -- ./server --port 8080\n" +
+			"run name1,/path1 -- env\n\n" +
+			"Reads names and paths like get and runs the command with the parameters as environment variables,\n" +
+			"without writing them to a file. The inherited environment is kept, parameters override variables\n" +
+			"which are already set unless --no-override is given. Use --clean-env to only pass the parameters.\n" +
+			"Signals are forwarded to the command, except Ctrl-C and other signals it gets from the terminal directly,\n" +
+			"and its exit code is returned.\n" +
+			"Use --help for help on the flags: --injson --upper --norecursive --prefixpath --prefixnormalizedpath " +
+			"--clean-env --no-override --concurrency",
+		Run: func(cmd *cobra.Command, args []string) {
+			inJsonFlag, _ := cmd.Flags().GetBool("injson")
+			upperFlag, _ := cmd.Flags().GetBool("upper")
+			noRecursiveFlag, _ := cmd.Flags().GetBool("norecursive")
+			prefixPathFlag, _ := cmd.Flags().GetBool("prefixpath")
+			prefixNormalizedPathFlag, _ := cmd.Flags().GetBool("prefixnormalizedpath")
+			cleanEnvFlag, _ := cmd.Flags().GetBool("clean-env")
+			noOverrideFlag, _ := cmd.Flags().GetBool("no-override")
+			concurrencyFlag, _ := cmd.Flags().GetInt("concurrency")
+			flags := util.Flags{
+				InJson:               inJsonFlag,
+				Upper:                upperFlag,
+				Recursive:            !noRecursiveFlag,
+				PrefixPath:           prefixPathFlag,
+				PrefixNormalizedPath: prefixNormalizedPathFlag,
+				Concurrency:          concurrencyFlag,
+			}
+			log.Debug().Msgf("Names/Paths: %s Command: %v", args[0], args[1:])
+			log.Debug().Msgf("Flags: %+v", flags)
+
+			ssmClient := newSSM(util.SessionOptions{})
+			result, err := ssmClient.GetParams(&args[0], flags)
+			if err != nil {
+				log.Error().Msg(err.Error())
+				os.Exit(1)
+				return
+			}
+			environ, err := util.MergeEnvironment(os.Environ(), result, cleanEnvFlag, noOverrideFlag)
+			if err != nil {
+				log.Error().Msg(err.Error())
+				os.Exit(1)
+				return
+			}
+			exitCode, err := util.RunCommand(args[1], args[2:], environ)
+			if err != nil {
+				log.Error().Msg(err.Error())
+			}
+			os.Exit(exitCode)
+		},
+	}
+	execCmd.PersistentFlags().Bool("injson", false, "Parse input parameter values as json and extract each json value as variable. Each has to be json.")
+	execCmd.PersistentFlags().Bool("upper", false, "Make variable names uppercase")
+	execCmd.PersistentFlags().Bool("norecursive", false, "Do not read recursively if getting a path")
+	execCmd.PersistentFlags().Bool("prefixpath", false, "Prefix variable names with the path")
+	execCmd.PersistentFlags().Bool("prefixnormalizedpath", false, "Prefix variable names with the normalized path")
+	execCmd.PersistentFlags().Int("concurrency", 1, "Read this many paths and chunks of 10 names at the same time")
+	execCmd.PersistentFlags().Bool("clean-env", false, "Do not inherit the environment, only pass the parameters to the command")
+	execCmd.PersistentFlags().Bool("no-override", false, "Keep variables which are already set in the environment")
+	rootCmd.AddCommand(execCmd)
+}
@@ -10,7 +10,13 @@
 
 func init() { // nolint: gochecknoinits
 	getCmd := &cobra.Command{
-		Args:  cobra.MinimumNArgs(1),
+		Args: func(cmd *cobra.Command, args []string) error {
+			// with tags the names are optional
+			if tags, _ := cmd.Flags().GetStringToString("tag"); len(tags) > 0 {
+				return cobra.MaximumNArgs(1)(cmd, args)
+			}
+			return cobra.MinimumNArgs(1)(cmd, args)
+		},
 		Use:   "get [names]",
 		Short: "get name1,/path1,/path2/subpath",
 		Long: "get name1,/path1,/path2/subpath,name3,name4\n\n" +
@@ -20,7 +26,14 @@ func init() { // nolint: gochecknoinits
 			"This can be piped into an file (> .env), to be included via --env-file=.env\n" +
 			"or to be set in a shell environment (not recommended): export $(cat .env).\n" +
 			"Note: name output is unique, if two paths parameters have the same name, the value of the last name in the list wins\n" +
-			"Use --help for help on the flags: --export --injson --outjson --upper --quote --norecursive --prefixpath --prefixnormalizedpath",
+			"Select a version or label with name:3 or name:label, a label also works on paths: /path:label\n" +
+			"With --tag team=payments only parameters with all given tags are returned, the names and paths are optional then.\n" +
+			"Names like sm://prod/db-credentials are read from Secrets Manager, with --secretsmanager all names are.\n" +
+			"Use --output yaml for yaml, together with --prefixpath and --nested the yaml mirrors the ssm hierarchy.\n" +
+			"Use --output k8s-secret or --output k8s-configmap with --name for a kubernetes manifest,\n" +
+			"with --split-by-type SecureStrings go to a Secret and all other values to a ConfigMap.\n" +
+			"Use --help for help on the flags: --export --injson --outjson --output --nested --upper --quote --norecursive " +
+			"--prefixpath --prefixnormalizedpath --tag --name --namespace --split-by-type --concurrency --secretsmanager",
 		Run: func(cmd *cobra.Command, args []string) {
 			exportFlag, _ := cmd.Flags().GetBool("export")
 			inJsonFlag, _ := cmd.Flags().GetBool("injson")
@@ -32,28 +45,47 @@ func init() { // nolint: gochecknoinits
 			recursiveFlag := !noRecursiveFlag
 			prefixPathFlag, _ := cmd.Flags().GetBool("prefixpath")
 			prefixNormalizedPathFlag, _ := cmd.Flags().GetBool("prefixnormalizedpath")
+			tagFlag, _ := cmd.Flags().GetStringToString("tag")
+			outputFlag, _ := cmd.Flags().GetString("output")
+			nestedFlag, _ := cmd.Flags().GetBool("nested")
+			nameFlag, _ := cmd.Flags().GetString("name")
+			namespaceFlag, _ := cmd.Flags().GetString("namespace")
+			splitByTypeFlag, _ := cmd.Flags().GetBool("split-by-type")
+			concurrencyFlag, _ := cmd.Flags().GetInt("concurrency")
+			secretsManagerFlag, _ := cmd.Flags().GetBool("secretsmanager")
 			flags := util.Flags{
-				exportFlag,
-				inJsonFlag,
-				outJsonFlag,
-				upperFlag,
-				quoteFlag,
-				false,
-				recursiveFlag,
-				prefixPathFlag,
-				prefixNormalizedPathFlag,
+				Export:               exportFlag,
+				InJson:               inJsonFlag,
+				OutJson:              outJsonFlag,
+				Upper:                upperFlag,
+				Quote:                quoteFlag,
+				Recursive:            recursiveFlag,
+				PrefixPath:           prefixPathFlag,
+				PrefixNormalizedPath: prefixNormalizedPathFlag,
+				Tags:                 tagFlag,
+				Output:               outputFlag,
+				Nested:               nestedFlag,
+				ManifestName:         nameFlag,
+				Namespace:            namespaceFlag,
+				SplitByType:          splitByTypeFlag,
+				Concurrency:          concurrencyFlag,
+				SecretsManager:       secretsManagerFlag,
+			}
+			names := ""
+			if len(args) > 0 {
+				names = args[0]
 			}
-			log.Debug().Msgf("Names/Paths: %s", args[0])
+			log.Debug().Msgf("Names/Paths: %s", names)
 			log.Debug().Msgf("Flags: %+v", flags)
-			ssmClient := util.NewSSM()
-			result, err := ssmClient.GetParams(&args[0], flags)
+			ssmClient := newSSM(util.SessionOptions{})
+			result, err := ssmClient.GetParamsDetailed(&names, flags)
 			if err != nil {
 				log.Error().Msg(err.Error())
 				os.Exit(1)
 				return
 			}
 
-			output, err := ssmClient.GetOutputString(result, flags)
+			output, err := ssmClient.GetParamsOutputString(result, flags)
 			if err != nil {
 				log.Error().Msg(err.Error())
 				os.Exit(1)
@@ -64,12 +96,20 @@ func init() { // nolint: gochecknoinits
 	}
 	getCmd.PersistentFlags().Bool("export", false, "Prefix output with export to eval it in shell")
 	getCmd.PersistentFlags().Bool("injson", false, "Parse input parameter values as json and extract each json value as output. Each has to be json.")
-	getCmd.PersistentFlags().Bool("outjson", false, "Output everything as a json file. Does not make sense together with --export.")
+	getCmd.PersistentFlags().Bool("outjson", false, "Output everything as a json file. Does not make sense together with --export. Same as --output json.")
+	getCmd.PersistentFlags().String("output", "", "Output format: env, json, yaml, k8s-secret or k8s-configmap. Default is env.")
+	getCmd.PersistentFlags().Bool("nested", false, "Output json or yaml as nested maps along the path of the names, use together with --prefixpath")
 	getCmd.PersistentFlags().Bool("upper", false, "Make keys uppercase")
 	getCmd.PersistentFlags().Bool("quote", false, "Wrap values in quotes")
 	getCmd.PersistentFlags().Bool("norecursive", false, "Do not read recursively if getting a path")
 	getCmd.PersistentFlags().Bool("prefixpath", false, "Prefix names with the path")
 	getCmd.PersistentFlags().Bool("prefixnormalizedpath", false, "Prefix names with the normalized path")
+	getCmd.PersistentFlags().String("name", "", "Name of the kubernetes Secret or ConfigMap")
+	getCmd.PersistentFlags().String("namespace", "", "Namespace of the kubernetes Secret or ConfigMap")
+	getCmd.PersistentFlags().Bool("split-by-type", false, "Kubernetes output of SecureStrings as Secret and all other values as ConfigMap")
+	getCmd.PersistentFlags().Int("concurrency", 1, "Read this many paths and chunks of 10 names at the same time")
+	getCmd.PersistentFlags().Bool("secretsmanager", false, "Read all names as secrets of Secrets Manager, like names with the sm:// prefix")
+	getCmd.PersistentFlags().StringToString("tag", nil, "Only get parameters with this tag, as key=value. Can be given multiple times.")
 	rootCmd.AddCommand(getCmd)
 
 }
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/gork74/aws-parameter-bulk/pkg/util"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+func init() { // nolint: gochecknoinits
+	historyCmd := &cobra.Command{
+		Args:  cobra.ExactArgs(1),
+		Use:   "history [names]",
+		Short: "history /dev/app/db_password",
+		Long: "history /dev/app/db_password\n" +
+			"history /dev/app,someparam1\n\n" +
+			"Lists all versions of single names and of all names under paths, with the modification date,\n" +
+			"the user who modified them and their labels. Values are masked unless --show-values is given.\n" +
+			"Use --help for help on the flags: --show-values --norecursive",
+		Run: func(cmd *cobra.Command, args []string) {
+			showValuesFlag, _ := cmd.Flags().GetBool("show-values")
+			noRecursiveFlag, _ := cmd.Flags().GetBool("norecursive")
+			flags := util.Flags{
+				Recursive: !noRecursiveFlag,
+			}
+			log.Debug().Msgf("Names/Paths: %s", args[0])
+			log.Debug().Msgf("Flags: %+v", flags)
+
+			ssmClient := newSSM(util.SessionOptions{})
+			versions, err := ssmClient.GetHistory(&args[0], flags)
+			if err != nil {
+				log.Error().Msg(err.Error())
+				os.Exit(1)
+				return
+			}
+			fmt.Print(util.GetHistoryOutputString(versions, showValuesFlag))
+		},
+	}
+	historyCmd.PersistentFlags().Bool("show-values", false, "Show the values of each version, instead of masking them")
+	historyCmd.PersistentFlags().Bool("norecursive", false, "Do not read recursively if getting a path")
+	rootCmd.AddCommand(historyCmd)
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/gork74/aws-parameter-bulk/pkg/util"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+func init() { // nolint: gochecknoinits
+	labelCmd := &cobra.Command{
+		Args:  cobra.ExactArgs(1),
+		Use:   "label [names]",
+		Short: "label /prod/app --label release-42",
+		Long: "label /prod/app --label release-42\n" +
+			"label /prod/app,someparam1 --label release-42 --remove\n\n" +
+			"Attaches the label to the current version of single names and of all names under paths.\n" +
+			"A label is on at most one version of a parameter, it is moved from older versions.\n" +
+			"With --remove the label is removed from the version which has it.\n" +
+			"Read the labelled versions with get /prod/app:release-42, or roll back to them with rollback --to-label.\n" +
+			"Use --help for help on the flags: --label --remove --dry --norecursive",
+		Run: func(cmd *cobra.Command, args []string) {
+			labelFlag, _ := cmd.Flags().GetString("label")
+			removeFlag, _ := cmd.Flags().GetBool("remove")
+			dryFlag, _ := cmd.Flags().GetBool("dry")
+			noRecursiveFlag, _ := cmd.Flags().GetBool("norecursive")
+			flags := util.Flags{
+				Dry:       dryFlag,
+				Recursive: !noRecursiveFlag,
+			}
+			log.Debug().Msgf("Names/Paths: %s Label: %s Remove: %t", args[0], labelFlag, removeFlag)
+			log.Debug().Msgf("Flags: %+v", flags)
+
+			if err := util.ValidateLabel(labelFlag); err != nil {
+				log.Error().Msg(err.Error())
+				os.Exit(1)
+				return
+			}
+			for _, param := range util.SplitParams(&args[0]) {
+				if _, selector := util.SplitSelector(param); selector != "" {
+					log.Error().Msgf("Labels are set on the current version, selectors can not be used: %s", param)
+					os.Exit(1)
+					return
+				}
+			}
+
+			ssmClient := newSSM(util.SessionOptions{})
+			names, err := ssmClient.ResolveParameterNames(&args[0], flags)
+			if err != nil {
+				log.Error().Msg(err.Error())
+				os.Exit(1)
+				return
+			}
+
+			if dryFlag {
+				fmt.Println("### Dry run, not labelling, this would have been labelled:")
+				for _, name := range names {
+					fmt.Println(name)
+				}
+				return
+			}
+			if removeFlag {
+				err = ssmClient.UnlabelParameters(names, labelFlag)
+			} else {
+				err = ssmClient.LabelParameters(names, labelFlag)
+			}
+			if err != nil {
+				log.Error().Msg(err.Error())
+				os.Exit(1)
+				return
+			}
+		},
+	}
+	labelCmd.PersistentFlags().String("label", "", "The label to attach or remove")
+	labelCmd.PersistentFlags().Bool("remove", false, "Remove the label instead of attaching it")
+	labelCmd.PersistentFlags().Bool("dry", false, "Dry run, just output what would be labelled and do nothing.")
+	labelCmd.PersistentFlags().Bool("norecursive", false, "Do not label recursively if labelling a path")
+	rootCmd.AddCommand(labelCmd)
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gork74/aws-parameter-bulk/pkg/util"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+func init() { // nolint: gochecknoinits
+	renderCmd := &cobra.Command{
+		Args:  cobra.ExactArgs(1),
+		Use:   "render [template]",
+		Short: "render app.conf.tmpl > app.conf",
+		Long: "render app.conf.tmpl > app.conf\n\n" +
+			"Renders a go template and fills it with parameters, using these functions:\n" +
+			"{{ ssm \"/dev/app/db_host\" }} the value of a name\n" +
+			"{{ range $key, $value := ssmPath \"/dev/app\" }}{{ $key }}={{ $value }}{{ end }} the names below a path\n" +
+			"{{ ssmJson \"jsonparam1\" \"key\" }} a key of a name containing json\n" +
+			"Missing parameters are an error, unless a --default value is given.\n" +
+			"Use --help for help on the flags: --default --norecursive",
+		Run: func(cmd *cobra.Command, args []string) {
+			defaultFlag, _ := cmd.Flags().GetString("default")
+			noRecursiveFlag, _ := cmd.Flags().GetBool("norecursive")
+			options := util.RenderOptions{
+				Default:    defaultFlag,
+				UseDefault: cmd.Flags().Changed("default"),
+				Recursive:  !noRecursiveFlag,
+			}
+			log.Debug().Msgf("Template: %s", args[0])
+			log.Debug().Msgf("Options: %+v", options)
+
+			text, err := os.ReadFile(args[0])
+			if err != nil {
+				log.Error().Msg(err.Error())
+				os.Exit(1)
+				return
+			}
+			ssmClient := newSSM(util.SessionOptions{})
+			output, err := ssmClient.RenderTemplate(filepath.Base(args[0]), string(text), options)
+			if err != nil {
+				log.Error().Msg(err.Error())
+				os.Exit(1)
+				return
+			}
+			fmt.Print(output)
+		},
+	}
+	renderCmd.PersistentFlags().String("default", "", "Value for missing parameters, instead of an error")
+	renderCmd.PersistentFlags().Bool("norecursive", false, "Do not read recursively for ssmPath")
+	rootCmd.AddCommand(renderCmd)
+}
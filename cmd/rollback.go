@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/gork74/aws-parameter-bulk/pkg/util"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+func init() { // nolint: gochecknoinits
+	rollbackCmd := &cobra.Command{
+		Args:  cobra.ExactArgs(1),
+		Use:   "rollback [names]",
+		Short: "rollback /prod/app --to 2026-10-01T12:00:00Z",
+		Long: "rollback /prod/app --to 2026-10-01T12:00:00Z\n" +
+			"rollback /prod/app,someparam1 --to-label release-42\n\n" +
+			"Finds the version in effect at the time, or the version with the label, for every parameter of the names and paths.\n" +
+			"Shows a diff against the current values and saves the changed values again, after a confirmation unless --yes is given.\n" +
+			"Parameters without such a version are kept, or deleted with --delete-newer.\n" +
+			"Use --help for help on the flags: --to --to-label --delete-newer --dry --yes --novalues --norecursive",
+		Run: func(cmd *cobra.Command, args []string) {
+			toFlag, _ := cmd.Flags().GetString("to")
+			labelFlag, _ := cmd.Flags().GetString("to-label")
+			deleteNewerFlag, _ := cmd.Flags().GetBool("delete-newer")
+			dryFlag, _ := cmd.Flags().GetBool("dry")
+			yesFlag, _ := cmd.Flags().GetBool("yes")
+			noValuesFlag, _ := cmd.Flags().GetBool("novalues")
+			noRecursiveFlag, _ := cmd.Flags().GetBool("norecursive")
+
+			if (toFlag == "") == (labelFlag == "") {
+				log.Error().Msg("Either --to or --to-label is needed")
+				os.Exit(1)
+				return
+			}
+			options := util.RollbackOptions{
+				Label:       labelFlag,
+				Recursive:   !noRecursiveFlag,
+				DeleteNewer: deleteNewerFlag,
+			}
+			target := labelFlag
+			if toFlag != "" {
+				to, err := time.Parse(time.RFC3339, toFlag)
+				if err != nil {
+					log.Error().Msgf("Invalid time for --to, use RFC3339 like 2026-10-01T12:00:00Z: %s", toFlag)
+					os.Exit(1)
+					return
+				}
+				options.To = to
+				target = to.Format(time.RFC3339)
+			}
+			log.Debug().Msgf("Names/Paths: %s", args[0])
+			log.Debug().Msgf("Options: %+v", options)
+
+			for _, param := range util.SplitParams(&args[0]) {
+				if _, selector := util.SplitSelector(param); selector != "" {
+					log.Error().Msgf("Rollback reads all versions, selectors can not be used: %s", param)
+					os.Exit(1)
+					return
+				}
+			}
+
+			ssmClient := newSSM(util.SessionOptions{})
+			plan, err := ssmClient.PlanRollback(&args[0], options)
+			if err != nil {
+				log.Error().Msg(err.Error())
+				os.Exit(1)
+				return
+			}
+
+			entries := plan.Diff()
+			if len(plan.Restore()) == 0 && len(plan.Delete) == 0 {
+				fmt.Println("Nothing to roll back")
+				return
+			}
+			output, err := util.GetDiffOutputString(entries, "current", target, util.DiffFormatText, !noValuesFlag)
+			if err != nil {
+				log.Error().Msg(err.Error())
+				os.Exit(1)
+				return
+			}
+			if dryFlag {
+				fmt.Println("### Dry run, not rolling back, this would have been changed:")
+			}
+			fmt.Print(output)
+			if dryFlag {
+				return
+			}
+			if !yesFlag && !confirm(fmt.Sprintf("Roll back %d and delete %d parameter(s)?", len(plan.Restore()), len(plan.Delete))) {
+				fmt.Println("Aborted, nothing rolled back")
+				os.Exit(1)
+				return
+			}
+
+			err = ssmClient.ApplyRollback(plan)
+			if err != nil {
+				log.Error().Msg(err.Error())
+				os.Exit(1)
+				return
+			}
+		},
+	}
+	rollbackCmd.PersistentFlags().String("to", "", "Roll back to the versions in effect at this time, in RFC3339 like 2026-10-01T12:00:00Z")
+	rollbackCmd.PersistentFlags().String("to-label", "", "Roll back to the versions with this label")
+	rollbackCmd.PersistentFlags().Bool("delete-newer", false, "Delete parameters which did not exist at the time or have no version with the label")
+	rollbackCmd.PersistentFlags().Bool("dry", false, "Dry run, just output what would be changed and do nothing.")
+	rollbackCmd.PersistentFlags().Bool("yes", false, "Do not ask for a confirmation before rolling back")
+	rollbackCmd.PersistentFlags().Bool("novalues", false, "Mask the values in the diff, only show which keys change")
+	rollbackCmd.PersistentFlags().Bool("norecursive", false, "Do not read recursively if rolling back a path")
+	rootCmd.AddCommand(rollbackCmd)
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -8,6 +9,7 @@
 	"time"
 
 	"github.com/gork74/aws-parameter-bulk/conf"
+	"github.com/gork74/aws-parameter-bulk/pkg/util"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -35,6 +37,13 @@ func main() {
 func init() { // nolint: gochecknoinits
 	// Initialize configuration
 	cobra.OnInitialize(conf.BindEnv, initConfig, initLog)
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "",
+		"Config file, default is $HOME/."+conf.Executable+".yaml if it exists")
+	rootCmd.PersistentFlags().Int("max-attempts", util.DefaultMaxAttempts,
+		"Calls to SSM before giving up when throttled, 1 disables retries. Config file: retry.maxattempts")
+	_ = viper.BindPFlag("retry.maxattempts", rootCmd.PersistentFlags().Lookup("max-attempts"))
+	rootCmd.PersistentFlags().Bool("no-cache", false, "Do not read from the cache, read from SSM and refresh the cache")
+	_ = viper.BindPFlag("cache.refresh", rootCmd.PersistentFlags().Lookup("no-cache"))
 }
 
 // Execute starts the program
@@ -59,6 +68,25 @@ func initConfig() {
 
 	viper.Set("logger.level", viper.GetString("SSM_LOG_LEVEL"))
 
+	if configFile != "" {
+		viper.SetConfigFile(configFile)
+	} else {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return
+		}
+		viper.AddConfigPath(home)
+		viper.SetConfigName("." + conf.Executable)
+		viper.SetConfigType("yaml")
+	}
+	if err := viper.ReadInConfig(); err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		// the default config file is optional, an explicitly given one is not
+		if configFile != "" || !errors.As(err, &notFound) {
+			fmt.Fprintf(os.Stderr, "Failed to read config file: %s\n", err.Error())
+			os.Exit(1)
+		}
+	}
 }
 
 func initLog() {
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/gork74/aws-parameter-bulk/pkg/util"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
@@ -14,7 +16,15 @@ func init() { // nolint: gochecknoinits
 		Long: "save .env\n" +
 			"save .env /basepath\n\n" +
 			"saves each entry from a file in the .env format (KEY=value) into multiple variables in the form key=value\n" +
-			"or saves them into multiple variables in the form /basepath/key=value",
+			"or saves them into multiple variables in the form /basepath/key=value\n" +
+			"Existing parameters keep their type, new parameters are saved as SecureString, unless --type is given.\n" +
+			"With --injson each key can set its own type: {\"KEY\": {\"Value\": \"value\", \"Type\": \"String\"}}\n" +
+			"SecureStrings are encrypted with --kms-key-id, or with the key the config file maps to their path:\n" +
+			"kms:\n  - path: /prod\n    keyid: alias/prod\n" +
+			"Use --tag team=payments to tag every saved parameter.\n\n" +
+			"save .env prod/app-config --target secretsmanager\n\n" +
+			"saves all entries as one JSON secret in Secrets Manager, which is created if it does not exist.\n" +
+			"Read it back with get sm://prod/app-config --injson",
 		Run: func(cmd *cobra.Command, args []string) {
 			fileName := args[0]
 			path := ""
@@ -23,22 +33,36 @@ func init() { // nolint: gochecknoinits
 			}
 			inJsonFlag, _ := cmd.Flags().GetBool("injson")
 			dryFlag, _ := cmd.Flags().GetBool("dry")
+			typeFlag, _ := cmd.Flags().GetString("type")
+			kmsKeyIdFlag, _ := cmd.Flags().GetString("kms-key-id")
+			tagFlag, _ := cmd.Flags().GetStringToString("tag")
+			targetFlag, _ := cmd.Flags().GetString("target")
 			flags := util.Flags{
-				false,
-				inJsonFlag,
-				false,
-				false,
-				false,
-				dryFlag,
-				false,
-				false,
-				false,
+				InJson:   inJsonFlag,
+				Dry:      dryFlag,
+				Type:     typeFlag,
+				KmsKeyId: kmsKeyIdFlag,
+				Tags:     tagFlag,
 			}
 			log.Debug().Msgf("Filename: %s Path: %s", fileName, path)
 			log.Debug().Msgf("Flags: %+v", flags)
 
-			ssmClient := util.NewSSM()
-			err := ssmClient.SaveParametersFromFile(fileName, path, flags)
+			ssmClient := newSSM(util.SessionOptions{})
+			var err error
+			switch targetFlag {
+			case "ssm":
+				err = ssmClient.SaveParametersFromFile(fileName, path, flags)
+			case "secretsmanager":
+				if path == "" {
+					err = fmt.Errorf("The name of the secret is missing: save .env prod/app-config --target secretsmanager")
+				} else if typeFlag != "" {
+					err = fmt.Errorf("Secrets have no type, --type only works with ssm")
+				} else {
+					err = ssmClient.SaveSecretFromFile(fileName, path, flags)
+				}
+			default:
+				err = fmt.Errorf("Unknown target %s, use ssm or secretsmanager", targetFlag)
+			}
 			if err != nil {
 				log.Error().Msg(err.Error())
 				return
@@ -47,5 +71,11 @@ func init() { // nolint: gochecknoinits
 	}
 	saveCmd.PersistentFlags().Bool("injson", false, "Parse input file as json and extract each json value as output.")
 	saveCmd.PersistentFlags().Bool("dry", false, "Dry run, just output what would be saved to ssm and do nothing.")
+	saveCmd.PersistentFlags().String("type", "", "Type of all saved parameters: String, StringList or SecureString. "+
+		"Default is the type of the existing parameter, or SecureString for new parameters.")
+	saveCmd.PersistentFlags().String("kms-key-id", "", "KMS key id, arn or alias to encrypt SecureStrings with. "+
+		"Default is the key mapped to the path in the config file, or the aws managed key.")
+	saveCmd.PersistentFlags().String("target", "ssm", "Where to save: ssm, or secretsmanager to save all entries as one JSON secret named by the basepath.")
+	saveCmd.PersistentFlags().StringToString("tag", nil, "Tag to add to every saved parameter, as key=value. Can be given multiple times.")
 	rootCmd.AddCommand(saveCmd)
 }
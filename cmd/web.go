@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"github.com/gork74/aws-parameter-bulk/pkg/util"
 	"github.com/gork74/aws-parameter-bulk/server"
 
 	"github.com/spf13/cobra"
@@ -18,7 +19,7 @@ func init() { // nolint: gochecknoinits
 			if address == "" {
 				address = ":8888"
 			}
-			server.ListenAndServe(&logger, address)
+			server.ListenAndServe(&logger, address, newSSM(util.SessionOptions{}))
 		},
 	}
 	webCmd.PersistentFlags().String("address", ":8888", "Ip and Port where the webserver is started, you can leave out the ip as a shortcut.")
@@ -14,6 +14,10 @@
 
 		viper.SetDefault("SSM_LOG_LEVEL", "info")
 
+		// the cache of get results is opt-in
+		viper.SetDefault("cache.enabled", false)
+		viper.SetDefault("cache.ttl", "5m")
+
 		return struct{}{}
 	}()
 )
@@ -1,13 +1,74 @@
 package models
 
+import "strings"
+
 type ValueCompare struct {
 	LeftName      string
 	LeftOriginal  string
 	LeftValue     string
 	LeftBasePath  string
+	LeftMissing   bool
+	LeftSecret    bool
 	RightName     string
 	RightOriginal string
 	RightValue    string
 	RightBasePath string
+	RightMissing  bool
+	RightSecret   bool
 	Different     bool
 }
+
+// ValueChange is a value which was saved, compared to its original value
+type ValueChange struct {
+	Name     string
+	Original string
+	Value    string
+}
+
+// LeftParameterName returns the full ssm name of the left value
+func (c ValueCompare) LeftParameterName() string {
+	return JoinBasePath(c.LeftBasePath, c.LeftName)
+}
+
+// RightParameterName returns the full ssm name of the right value
+func (c ValueCompare) RightParameterName() string {
+	return JoinBasePath(c.RightBasePath, c.RightName)
+}
+
+// BasePath returns the path of a full ssm name without the name itself, "/" for root names and "" for names without a path
+func BasePath(name string) string {
+	index := strings.LastIndex(name, "/")
+	if index < 0 {
+		return ""
+	}
+	if index == 0 {
+		return "/"
+	}
+	return name[:index]
+}
+
+// JoinBasePath is the reverse of BasePath
+func JoinBasePath(basePath string, name string) string {
+	if basePath == "" {
+		return name
+	}
+	return strings.TrimSuffix(basePath, "/") + "/" + name
+}
+
+// ValueCopy is a pending write of a value from one side of a comparison to the other side
+type ValueCopy struct {
+	ValueChange
+	Row      int
+	ToLeft   bool
+	Key      string
+	BasePath string
+}
+
+// ParameterVersion is one version of a parameter for the history view, the value is masked if it is not shown
+type ParameterVersion struct {
+	Version  int64
+	Modified string
+	User     string
+	Labels   string
+	Value    string
+}
@@ -1,11 +1,13 @@
 package util
 
 import (
-	"bufio"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
 	"github.com/aws/aws-sdk-go/service/ssm"
 	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
 	"github.com/rs/zerolog/log"
@@ -19,6 +21,9 @@
 	trueBool        = true
 	parameterType   = "SecureString"
 	ErrNameNotFound = errors.New("Name not found")
+	ErrInvalidType  = errors.New("Invalid parameter type, use String, StringList or SecureString")
+	// ErrVersionOnPath is returned for a version selector on a path, versions are only selectable for single names
+	ErrVersionOnPath = errors.New("Version selector can only be used on single names, use a label for paths")
 )
 
 type Flags struct {
@@ -31,11 +36,62 @@ type Flags struct {
 	Recursive            bool
 	PrefixPath           bool
 	PrefixNormalizedPath bool
+	// Type is the parameter type to save with, if empty the type of an existing parameter is kept
+	Type string
+	// KmsKeyId is the kms key to save SecureStrings with, it takes precedence over the KmsKeys of AWSSSM
+	KmsKeyId string
+	// Tags are added to saved parameters, on reading only parameters with all tags are selected
+	Tags map[string]string
+	// Output is the output format: env, json or yaml. OutJson is the same as json.
+	Output string
+	// Nested outputs json or yaml as nested maps along the path of the names
+	Nested bool
+	// ManifestName and Namespace are the metadata of kubernetes manifest output
+	ManifestName string
+	Namespace    string
+	// SplitByType writes SecureStrings to a Secret and all other values to a ConfigMap
+	SplitByType bool
+	// Concurrency is the number of paths and chunks of names read at the same time, 0 and 1 read one after another
+	Concurrency int
+	// SecretsManager reads all names as secrets of Secrets Manager, like names with the sm:// prefix
+	SecretsManager bool
+}
+
+// Parameter is a parameter as read from ssm. Name is the full name in ssm,
+// results are keyed by the output name which depends on the flags.
+type Parameter struct {
+	Name  string
+	Value string
+	Type  string
+	// SecretsManager is set for secrets, which can not be written or looked up like parameters of ssm
+	SecretsManager bool `json:",omitempty"`
+}
+
+// KmsKeyMapping selects the kms key for SecureStrings saved on a path and below
+type KmsKeyMapping struct {
+	Path  string `mapstructure:"path"`
+	KeyId string `mapstructure:"keyid"`
 }
 
 type AWSSSM struct {
 	session *session.Session
-	SSM     ssmiface.SSMAPI
+	// SSM is used by the default store and for features which only SSM has, like history, labels and tags
+	SSM ssmiface.SSMAPI
+	// Store reads and writes the parameters, nil uses SSM
+	Store Store
+	// SecretsManager reads the sm:// names and saves secrets
+	SecretsManager secretsmanageriface.SecretsManagerAPI
+	// KmsKeys are used to save SecureStrings on a path with a specific kms key
+	KmsKeys []KmsKeyMapping
+	// Retry is the policy for throttled and failed calls, the zero value does not retry
+	Retry RetryPolicy
+	// reads and writes limit the calls per second, nil does not limit
+	reads  *tokenBucket
+	writes *tokenBucket
+	// Cache stores the results of GetParamsDetailed, nil does not cache. Writes clear it.
+	Cache *Cache
+	// scope separates the cache entries of profiles, regions and credentials
+	scope string
 }
 
 func IsPath(param *string) (bool, error) {
@@ -57,33 +113,94 @@ func SplitParams(param *string) []string {
 	return []string{}
 }
 
+// SessionOptions selects the aws profile and region, empty values use the shared config and environment
+type SessionOptions struct {
+	Profile string
+	Region  string
+}
+
 func NewSSM() *AWSSSM {
-	// initialize aws SSM
-	session := session.Must(session.NewSessionWithOptions(session.Options{
+	return NewSSMWithOptions(SessionOptions{})
+}
+
+// NewSSMWithOptions creates a client for an explicit profile and region,
+// so one run can read from one account and write into another
+func NewSSMWithOptions(options SessionOptions) *AWSSSM {
+	sessionOptions := session.Options{
+		Profile:           options.Profile,
 		SharedConfigState: session.SharedConfigEnable,
-	}))
+	}
+	if options.Region != "" {
+		sessionOptions.Config.Region = aws.String(options.Region)
+	}
+	// retries are done by the RetryPolicy, together with the rate limit
+	sessionOptions.Config.MaxRetries = aws.Int(0)
+
+	// initialize aws SSM
+	session := session.Must(session.NewSessionWithOptions(sessionOptions))
 
 	SSM := ssm.New(session)
 
 	return &AWSSSM{
-		session: session,
-		SSM:     SSM,
+		session:        session,
+		SSM:            SSM,
+		SecretsManager: secretsmanager.New(session),
+		Retry:          DefaultRetryPolicy,
+		reads:          newTokenBucket(readsPerSecond),
+		writes:         newTokenBucket(writesPerSecond),
+		scope:          sessionScope(options, session),
+	}
+}
+
+// sessionScope identifies the profile, region and credentials of a session
+func sessionScope(options SessionOptions, session *session.Session) string {
+	profile := options.Profile
+	if profile == "" {
+		profile = os.Getenv("AWS_PROFILE")
+	}
+	if profile == "" {
+		profile = "default"
+	}
+	return strings.Join([]string{profile, aws.StringValue(session.Config.Region), os.Getenv("AWS_ACCESS_KEY_ID")}, "|")
+}
+
+// SplitSelector splits a name:version or name:label selector into the name and the version or label.
+// Names can not contain a colon, so everything after the last colon is the selector.
+func SplitSelector(param string) (string, string) {
+	index := strings.LastIndex(param, ":")
+	if index < 0 {
+		return param, ""
+	}
+	return param[:index], param[index+1:]
+}
+
+// isVersionSelector checks if a selector is a version number, labels can not start with a number
+func isVersionSelector(selector string) bool {
+	if selector == "" {
+		return false
+	}
+	for _, char := range selector {
+		if char < '0' || char > '9' {
+			return false
+		}
 	}
+	return true
 }
 
-func getNameAndValue(param *ssm.Parameter, flags Flags) (string, string, error) {
+func getNameAndValue(param Parameter, flags Flags) (string, string, error) {
+	paramName := param.Name
 	if flags.PrefixPath {
-		return getUpper(*param.Name, flags), *param.Value, nil
+		return getUpper(paramName, flags), param.Value, nil
 	} else if flags.PrefixNormalizedPath {
-		prefixPath := getUpper(*param.Name, flags)
-		// remove first occurrence
-		normalizedPath := strings.Replace(prefixPath, "/", "", 1)
+		prefixPath := getUpper(paramName, flags)
+		// remove the leading slash, names of secrets have none
+		normalizedPath := strings.TrimPrefix(prefixPath, "/")
 		normalizedPath = strings.ReplaceAll(normalizedPath, "/", "_")
-		return normalizedPath, *param.Value, nil
+		return normalizedPath, param.Value, nil
 	} else {
-		split := strings.Split(*param.Name, "/")
+		split := strings.Split(paramName, "/")
 		name := split[len(split)-1]
-		return getUpper(name, flags), *param.Value, nil
+		return getUpper(name, flags), param.Value, nil
 	}
 }
 
@@ -101,118 +218,112 @@ func chunkParamNames(paramNames []*string, chunkSize int) [][]*string {
 	return chunks
 }
 
-func (f *AWSSSM) GetParametersByPath(paths []string, flags Flags) (map[string]string, error) {
-	params := make(map[string]string)
-
-	// retrieve params for all paths
-	for _, path := range paths {
-		log.Debug().Msgf("Retrieving Path: %s", path)
-
-		done := false
-		var nextToken string
-		for !done {
-			input := &ssm.GetParametersByPathInput{
-				Path:           &path,
-				Recursive:      &flags.Recursive,
-				WithDecryption: &trueBool,
-			}
-
-			if nextToken != "" {
-				input.SetNextToken(nextToken)
-			}
-
-			output, err := f.SSM.GetParametersByPath(input)
-			if err != nil {
-				return params, err
-			}
-			log.Debug().Msgf("Retrieved Parameters for path %s: %s", path, output.Parameters)
-			if len(output.Parameters) == 0 {
-				// if no parameters are found, try to get the parameter as a single value
-				inputSingle := &ssm.GetParameterInput{
-					Name:           &path,
-					WithDecryption: &trueBool,
-				}
-				outputSingle, err := f.SSM.GetParameter(inputSingle)
-				if err != nil {
-					// if this also fails, no path or parameter on path exists
-					log.Error().Msgf("No names found for path: %s", path)
-					return params, ErrNameNotFound
-				}
-				nameSingle, value, _ := getNameAndValue(outputSingle.Parameter, flags)
-				log.Debug().Msgf("Retrieved Parameter for %s: %s", path, nameSingle)
-				params[nameSingle] = value
-				break
-			}
-
-			for _, param := range output.Parameters {
-				name, value, _ := getNameAndValue(param, flags)
-				log.Debug().Msgf("Name: %s Value %s", name, value)
-				params[name] = value
-			}
+func (f *AWSSSM) GetParametersByPath(paths []string, flags Flags) (map[string]Parameter, error) {
+	// each path is read into its own slot and merged in order, so later paths override earlier ones
+	results := make([]map[string]Parameter, len(paths))
+	err := runConcurrently(len(paths), flags.Concurrency, func(index int) error {
+		var err error
+		results[index], err = f.getParametersOfPath(paths[index], flags)
+		return err
+	})
+	return mergeParameters(results), err
+}
 
-			// if nextToken has a value, there are more parameters to fetch. maximum is 10 parameters at a time.
-			if output.NextToken != nil {
-				nextToken = *output.NextToken
-			} else {
-				done = true
+// getParametersOfPath reads all parameters of a path, or the path as single name if it has none
+func (f *AWSSSM) getParametersOfPath(selectedPath string, flags Flags) (map[string]Parameter, error) {
+	params := make(map[string]Parameter)
+	log.Debug().Msgf("Retrieving Path: %s", selectedPath)
+	_, selector := SplitSelector(selectedPath)
+
+	found := make([]Parameter, 0)
+	// a version selector can only select a single name, which is read below
+	if !isVersionSelector(selector) {
+		var err error
+		found, err = f.store().GetByPath(selectedPath, flags.Recursive)
+		if err != nil {
+			return params, err
+		}
+	}
+	if len(found) == 0 {
+		// if no parameters are found, try to get the parameter as a single value
+		single, err := f.store().GetByNames([]string{selectedPath})
+		if err != nil || len(single) == 0 {
+			// if this also fails, no path or parameter on path exists
+			log.Error().Msgf("No names found for path: %s", selectedPath)
+			if isVersionSelector(selector) {
+				return params, fmt.Errorf("%w: %s", ErrVersionOnPath, selectedPath)
 			}
+			return params, ErrNameNotFound
 		}
+		found = single
 	}
 
+	for _, param := range found {
+		name, value, _ := getNameAndValue(param, flags)
+		log.Debug().Msgf("Name: %s Value %s", name, value)
+		params[name] = param
+	}
 	return params, nil
 }
 
-func (f *AWSSSM) GetParameters(ssmnames []*string, flags Flags) (map[string]string, error) {
-	params := make(map[string]string)
-
+func (f *AWSSSM) GetParameters(ssmnames []*string, flags Flags) (map[string]Parameter, error) {
 	// GetParameters only supports at max of 10 params
 	chunks := chunkParamNames(ssmnames, 10)
 
-	// retrieve listed param names
-	for _, chunk := range chunks {
-		chunkNames := ""
-		for _, name := range chunk {
-			log.Debug().Msgf("Retrieving Name: %s", *name)
-			chunkNames += fmt.Sprintf("%s ", *name)
-		}
+	// retrieve listed param names, each chunk into its own slot to keep the order of the names
+	results := make([]map[string]Parameter, len(chunks))
+	err := runConcurrently(len(chunks), flags.Concurrency, func(index int) error {
+		var err error
+		results[index], err = f.getParametersChunk(chunks[index], flags)
+		return err
+	})
+	return mergeParameters(results), err
+}
 
-		input := &ssm.GetParametersInput{
-			Names:          chunk,
-			WithDecryption: &trueBool,
-		}
+// getParametersChunk reads up to 10 names
+func (f *AWSSSM) getParametersChunk(chunk []*string, flags Flags) (map[string]Parameter, error) {
+	params := make(map[string]Parameter)
+	names := aws.StringValueSlice(chunk)
+	log.Debug().Msgf("Retrieving Names: %v", names)
 
-		output, err := f.SSM.GetParameters(input)
-		if err != nil {
-			return params, err
-		}
-		if len(output.Parameters) == 0 {
-			log.Error().Msgf("None of the Names was found: %s", chunkNames)
-			return params, ErrNameNotFound
-		}
-		log.Debug().Msgf("Retrieved Parameters: %s", output.Parameters)
+	found, err := f.store().GetByNames(names)
+	if err != nil {
+		return params, err
+	}
+	if len(found) == 0 {
+		log.Error().Msgf("None of the Names was found: %s", strings.Join(names, " "))
+		return params, ErrNameNotFound
+	}
 
-		for _, param := range output.Parameters {
-			name, value, _ := getNameAndValue(param, flags)
-			log.Debug().Msgf("NAME: %s VALUE: %s", name, value)
-			params[name] = value
-		}
+	for _, param := range found {
+		name, value, _ := getNameAndValue(param, flags)
+		log.Debug().Msgf("NAME: %s VALUE: %s", name, value)
+		params[name] = param
 	}
 
 	return params, nil
 }
 
 func (f *AWSSSM) ReadParametersFromFile(fileName string, path string, flags Flags) (map[string]string, error) {
+	params, _, err := f.ReadTypedParametersFromFile(fileName, path, flags)
+	return params, err
+}
+
+// ReadTypedParametersFromFile works like ReadParametersFromFile, and additionally returns the types per key
+// of structured json input in the form {"KEY": {"Value": "value", "Type": "String"}}
+func (f *AWSSSM) ReadTypedParametersFromFile(fileName string, path string, flags Flags) (map[string]string, map[string]string, error) {
 	params := make(map[string]string)
+	types := make(map[string]string)
 
 	if path != "" {
 		isPath, err := IsPath(&path)
 		if err != nil {
 			log.Error().Msg(err.Error())
-			return params, err
+			return params, types, err
 		}
 		if !isPath {
 			log.Error().Msgf("Target is not a path: %s", path)
-			return params, errors.New("Target is not a path")
+			return params, types, errors.New("Target is not a path")
 		}
 	}
 
@@ -220,94 +331,163 @@ func (f *AWSSSM) ReadParametersFromFile(fileName string, path string, flags Flag
 		dat, err := os.ReadFile(fileName)
 		if err != nil {
 			log.Error().Msg(err.Error())
-			return params, err
+			return params, types, err
 		}
-		params, err = ExpandJson(string(dat))
+		params, types, err = ExpandTypedJson(string(dat))
 		if err != nil {
 			log.Error().Msg(err.Error())
-			return params, err
+			return params, types, err
 		}
 	} else {
-		file, err := os.Open(fileName)
+		dat, err := os.ReadFile(fileName)
 		if err != nil {
 			log.Error().Msg(err.Error())
-			return params, err
+			return params, types, err
 		}
-		defer file.Close()
-
-		scanner := bufio.NewScanner(file)
-		for scanner.Scan() {
-			log.Debug().Msgf("READ LINE: %s", scanner.Text())
-			if strings.Index(scanner.Text(), "=") < 1 {
-				log.Info().Msgf("Ignoring line: %s", scanner.Text())
-			} else {
-				data := strings.SplitN(scanner.Text(), "=", 2)
-				name := data[0]
-				value := data[1]
-				if name != "" {
-					log.Debug().Msgf("NAME: %s VALUE: %s", name, value)
-					params[name] = value
-				} else {
-					log.Info().Msgf("Ignoring line: %s", scanner.Text())
-				}
-			}
-		}
-		if err := scanner.Err(); err != nil {
-			log.Error().Msg(err.Error())
-			return params, err
+		params, err = ParseDotenv(string(dat))
+		if err != nil {
+			log.Error().Msgf("%s: %s", fileName, err.Error())
+			return params, types, err
 		}
 	}
-	return params, nil
+	return params, types, nil
 }
 
 func (f *AWSSSM) SaveParametersFromFile(fileName string, basePath string, flags Flags) error {
-	params, err := f.ReadParametersFromFile(fileName, basePath, flags)
+	params, types, err := f.ReadTypedParametersFromFile(fileName, basePath, flags)
+	if err != nil {
+		log.Error().Msg(err.Error())
+		return err
+	}
+	return f.SaveParameters(params, types, basePath, flags)
+}
+
+// SaveParameters saves the params by name, prefixed with the base path if given.
+// The type of each parameter is taken from types, then the type flag, then the type of the existing parameter,
+// and SecureString for new parameters.
+func (f *AWSSSM) SaveParameters(params map[string]string, types map[string]string, basePath string, flags Flags) error {
+	plan, err := f.planSave(params, types, basePath, flags)
 	if err != nil {
 		log.Error().Msg(err.Error())
 		return err
 	}
 	if flags.Dry {
-		prefix := ""
-		if basePath != "" {
-			prefix = basePath + "/"
-		}
-		result := OutputParamsAsString(params, prefix, flags)
 		fmt.Println("### Dry run, not saving, this would have been set:")
-		fmt.Println(result)
+		for _, param := range plan {
+			fmt.Printf("%s=%s (%s)\n", param.Name, param.Value, describeParameter(param))
+		}
 		return nil
 	}
-	return f.SaveParameters(params, basePath)
+	for index, param := range plan {
+		fmt.Printf("%s=%s\n", param.Name, param.Value)
+		_, err := f.PutParameterDetails(param, true)
+		if err != nil {
+			// list what is missing, so a half applied save can be completed
+			notSaved := make([]string, 0, len(plan)-index)
+			for _, missing := range plan[index:] {
+				notSaved = append(notSaved, missing.Name)
+			}
+			log.Error().Msgf("Saved %d of %d parameters, not saved: %s", index, len(plan), strings.Join(notSaved, ","))
+			return err
+		}
+	}
+
+	return nil
 }
 
-func (f *AWSSSM) SaveParameters(params map[string]string, basePath string) error {
-	var names []string
-	for param := range params {
-		names = append(names, param)
+// SaveParameter writes a single value to ssm, an existing value is overwritten.
+// If paramType is empty, the type of an existing parameter is kept.
+func (f *AWSSSM) SaveParameter(name string, value string, paramType string) error {
+	plan, err := f.planSave(map[string]string{name: value}, map[string]string{name: paramType}, "", Flags{})
+	if err != nil {
+		return err
 	}
-	sort.Strings(names)
-	for _, rawName := range names {
+	_, err = f.PutParameterDetails(plan[0], true)
+	return err
+}
+
+// planSave resolves the full name and the type of each parameter to save, sorted by name
+func (f *AWSSSM) planSave(params map[string]string, types map[string]string, basePath string, flags Flags) ([]ParameterDetails, error) {
+	if flags.Type != "" && !isValidType(flags.Type) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidType, flags.Type)
+	}
+
+	plan := make([]ParameterDetails, 0, len(params))
+	lookup := make([]string, 0)
+	for _, rawName := range GetSortedNamesFromParams(params) {
 		paramName := rawName
 		// construct a path if neccessary
 		if basePath != "" {
 			paramName = fmt.Sprintf("%s/%s", basePath, rawName)
 		}
-		value := params[rawName]
-		fmt.Printf("%s=%s\n", paramName, value)
-		input := &ssm.PutParameterInput{
-			Name:      &paramName,
-			Value:     &value,
-			Overwrite: &trueBool,
-			Type:      &parameterType,
+		paramType := types[rawName]
+		if paramType == "" {
+			paramType = flags.Type
+		}
+		if paramType == "" {
+			lookup = append(lookup, paramName)
+		} else if !isValidType(paramType) {
+			return nil, fmt.Errorf("%w: %s=%s", ErrInvalidType, rawName, paramType)
 		}
+		plan = append(plan, ParameterDetails{
+			Name:  paramName,
+			Value: params[rawName],
+			Type:  paramType,
+			Tags:  flags.Tags,
+		})
+	}
 
-		output, err := f.SSM.PutParameter(input)
-		if err != nil {
-			return err
+	existingTypes, err := f.GetParameterTypes(lookup)
+	if err != nil {
+		return nil, err
+	}
+	for index := range plan {
+		if plan[index].Type == "" {
+			plan[index].Type = existingTypes[plan[index].Name]
+		}
+		if plan[index].Type == "" {
+			plan[index].Type = parameterType
+		}
+		if plan[index].Type == ssm.ParameterTypeSecureString {
+			plan[index].KeyId = f.kmsKeyId(plan[index].Name, flags)
 		}
-		log.Info().Msgf("Output: %s", output)
 	}
+	return plan, nil
+}
 
-	return nil
+// kmsKeyId returns the kms key of the flags, or of the longest matching path of the KmsKeys.
+// Returns an empty string to use the default key.
+func (f *AWSSSM) kmsKeyId(name string, flags Flags) string {
+	if flags.KmsKeyId != "" {
+		return flags.KmsKeyId
+	}
+	keyId := ""
+	matched := -1
+	for _, mapping := range f.KmsKeys {
+		path := strings.TrimSuffix(strings.TrimSuffix(mapping.Path, "*"), "/")
+		if name != path && !strings.HasPrefix(name, path+"/") {
+			continue
+		}
+		if len(path) > matched {
+			keyId = mapping.KeyId
+			matched = len(path)
+		}
+	}
+	return keyId
+}
+
+// GetParameterTypes returns the types of the existing parameters by full name, names which do not exist are left out
+func (f *AWSSSM) GetParameterTypes(names []string) (map[string]string, error) {
+	return f.store().GetTypes(names)
+}
+
+func isValidType(paramType string) bool {
+	for _, validType := range ssm.ParameterType_Values() {
+		if paramType == validType {
+			return true
+		}
+	}
+	return false
 }
 
 func GetSortedNamesFromParams(params map[string]string) []string {
@@ -319,16 +499,12 @@ func GetSortedNamesFromParams(params map[string]string) []string {
 	return names
 }
 
-// outputs the parameters as string sorted by name
+// outputs the parameters as string sorted by name, values are quoted and escaped as needed
 func OutputParamsAsString(params map[string]string, prefix string, flags Flags) string {
 	names := GetSortedNamesFromParams(params)
 	var result = ""
 	for _, name := range names {
-		if flags.Quote {
-			result += fmt.Sprintf("%s%s=\"%s\"\n", prefix, name, params[name])
-		} else {
-			result += fmt.Sprintf("%s%s=%s\n", prefix, name, params[name])
-		}
+		result += fmt.Sprintf("%s%s=%s\n", prefix, name, FormatDotenvValue(params[name], flags.Export, flags.Quote))
 	}
 	return result
 }
@@ -346,22 +522,58 @@ func ExpandJson(value string) (map[string]string, error) {
 	for jkey := range jsonMap {
 		valueType := reflect.TypeOf(jsonMap[jkey])
 		log.Debug().Str("jkey", jkey).Interface("jsonMap[jkey]", jsonMap[jkey]).Interface("valueType", valueType).Msg("ExpandJson jsonMap")
-		switch jsonMap[jkey].(type) {
-		case int, int8, int16, int32, int64:
-			result[jkey] = fmt.Sprintf("%v", jsonMap[jkey])
-		case float32, float64:
-			// Integer "0" and float "0.0" result in a float type and hence are
-			// indistinguishable here, and output as integer
-			result[jkey] = fmt.Sprintf("%v", jsonMap[jkey])
-		case bool:
-			result[jkey] = fmt.Sprintf("%t", jsonMap[jkey])
-		default:
-			result[jkey] = fmt.Sprintf("%s", jsonMap[jkey])
-		}
+		result[jkey] = formatJsonValue(jsonMap[jkey])
 	}
 	return result, nil
 }
 
+// ExpandTypedJson works like ExpandJson, but accepts objects with a value and a type for each key:
+// {"KEY": {"Value": "value", "Type": "String"}}. Returns the values and the types by key.
+func ExpandTypedJson(value string) (map[string]string, map[string]string, error) {
+	result := make(map[string]string)
+	types := make(map[string]string)
+
+	log.Debug().Str("json", value).Msg("ExpandTypedJson")
+	jsonMap := make(map[string]interface{})
+	err := json.Unmarshal([]byte(value), &jsonMap)
+	if err != nil {
+		log.Error().Msgf("Error unmarshalling json: %s", err.Error())
+		return nil, nil, err
+	}
+	for jkey, jvalue := range jsonMap {
+		object, ok := jvalue.(map[string]interface{})
+		if !ok {
+			result[jkey] = formatJsonValue(jvalue)
+			continue
+		}
+		typedValue, ok := object["Value"]
+		if !ok {
+			log.Error().Msgf("Missing Value for key: %s", jkey)
+			return nil, nil, fmt.Errorf("missing Value for key %s", jkey)
+		}
+		result[jkey] = formatJsonValue(typedValue)
+		if typedType, ok := object["Type"]; ok {
+			types[jkey] = formatJsonValue(typedType)
+		}
+	}
+	return result, types, nil
+}
+
+func formatJsonValue(value interface{}) string {
+	switch value.(type) {
+	case int, int8, int16, int32, int64:
+		return fmt.Sprintf("%v", value)
+	case float32, float64:
+		// Integer "0" and float "0.0" result in a float type and hence are
+		// indistinguishable here, and output as integer
+		return fmt.Sprintf("%v", value)
+	case bool:
+		return fmt.Sprintf("%t", value)
+	default:
+		return fmt.Sprintf("%s", value)
+	}
+}
+
 func ExpandJsonParams(params map[string]string, flags Flags) (map[string]string, error) {
 	result := make(map[string]string)
 
@@ -382,6 +594,30 @@ func ExpandJsonParams(params map[string]string, flags Flags) (map[string]string,
 	return result, nil
 }
 
+// expandJsonParameters works like ExpandJsonParams, each json value keeps the name and type of its parameter
+func expandJsonParameters(params map[string]Parameter, flags Flags) (map[string]Parameter, error) {
+	result := make(map[string]Parameter)
+
+	for name, param := range params {
+		log.Debug().Str("name", name).Msg("expandJsonParameters")
+
+		valueMap, err := ExpandJson(param.Value)
+		if err != nil {
+			log.Error().Msgf("Error unmarshalling ssm parameter: %s / %s", name, err.Error())
+			return nil, err
+		}
+		for jkey := range valueMap {
+			result[getUpper(jkey, flags)] = Parameter{
+				Name:           param.Name,
+				Value:          valueMap[jkey],
+				Type:           param.Type,
+				SecretsManager: param.SecretsManager,
+			}
+		}
+	}
+	return result, nil
+}
+
 func getUpper(param string, flags Flags) string {
 	if flags.Upper {
 		return strings.ToUpper(param)
@@ -390,9 +626,48 @@ func getUpper(param string, flags Flags) string {
 }
 
 func (f *AWSSSM) GetParams(paramstring *string, flags Flags) (map[string]string, error) {
-	results := make(map[string]string)
+	results, err := f.GetParamsDetailed(paramstring, flags)
+	return GetValuesFromParams(results), err
+}
+
+// GetParamsDetailed works like GetParams, but keeps the full ssm name and the type of each parameter
+func (f *AWSSSM) GetParamsDetailed(paramstring *string, flags Flags) (map[string]Parameter, error) {
+	if f.Cache == nil {
+		return f.getParamsDetailed(paramstring, flags)
+	}
+	name := cacheName(f.scope, *paramstring, flags)
+	if results, ok := f.Cache.Get(name); ok {
+		return results, nil
+	}
+	results, err := f.getParamsDetailed(paramstring, flags)
+	if err != nil {
+		return results, err
+	}
+	if err := f.Cache.Put(name, results); err != nil {
+		log.Warn().Msgf("Failed to write cache: %s", err.Error())
+	}
+	return results, nil
+}
+
+func (f *AWSSSM) getParamsDetailed(paramstring *string, flags Flags) (map[string]Parameter, error) {
+	results := make(map[string]Parameter)
 
 	params := SplitParams(paramstring)
+	secretIds, params := splitSecretIds(params, flags)
+	if len(flags.Tags) > 0 {
+		// only SSM can filter by tags, the secrets would be left out silently
+		if len(secretIds) > 0 {
+			err := fmt.Errorf("%w: %s%s", ErrSecretsWithTags, SecretsPrefix, strings.Join(secretIds, ","+SecretsPrefix))
+			log.Error().Msg(err.Error())
+			return results, err
+		}
+		tagResults, err := f.getParametersByTags(params, flags)
+		if err != nil {
+			log.Error().Msg(err.Error())
+			return results, err
+		}
+		return f.expandResults(tagResults, flags)
+	}
 	paramNames := make([]*string, 0)
 	pathNames := make([]string, 0)
 
@@ -418,9 +693,9 @@ func (f *AWSSSM) GetParams(paramstring *string, flags Flags) (map[string]string,
 		return results, err
 	}
 
-	for name, value := range pathResults {
-		log.Debug().Msgf("Name: %s Value %s", name, value)
-		results[name] = value
+	for name, param := range pathResults {
+		log.Debug().Msgf("Name: %s Value %s", name, param.Value)
+		results[name] = param
 	}
 
 	singleResults, err := f.GetParameters(paramNames, flags)
@@ -429,37 +704,88 @@ func (f *AWSSSM) GetParams(paramstring *string, flags Flags) (map[string]string,
 		return results, err
 	}
 
-	for name, value := range singleResults {
-		log.Debug().Msgf("Name: %s Value %s", name, value)
-		results[name] = value
+	for name, param := range singleResults {
+		log.Debug().Msgf("Name: %s Value %s", name, param.Value)
+		results[name] = param
 	}
 
-	if flags.InJson {
-		results, err = ExpandJsonParams(results, flags)
+	// secrets are merged last, so they override parameters of ssm with the same name
+	if len(secretIds) > 0 {
+		secretResults, err := f.GetSecrets(secretIds, flags)
 		if err != nil {
 			log.Error().Msg(err.Error())
 			return results, err
 		}
+		for name, param := range secretResults {
+			results[name] = param
+		}
 	}
-	return results, nil
+
+	return f.expandResults(results, flags)
+}
+
+// expandResults expands json values, if the flags ask for it
+func (f *AWSSSM) expandResults(results map[string]Parameter, flags Flags) (map[string]Parameter, error) {
+	if !flags.InJson {
+		return results, nil
+	}
+	results, err := expandJsonParameters(results, flags)
+	if err != nil {
+		log.Error().Msg(err.Error())
+	}
+	return results, err
+}
+
+// GetValuesFromParams returns only the values of the parameters by output name
+func GetValuesFromParams(params map[string]Parameter) map[string]string {
+	values := make(map[string]string, len(params))
+	for name, param := range params {
+		values[name] = param.Value
+	}
+	return values
 }
 
 func (f *AWSSSM) GetOutputString(results map[string]string, flags Flags) (string, error) {
-	if flags.Export && flags.OutJson {
-		log.Error().Msg("--export and --outjson can not be used together")
-		return "", errors.New("export and outjson can not be used together")
+	format, err := getOutputFormat(flags)
+	if err != nil {
+		log.Error().Msg(err.Error())
+		return "", err
+	}
+	if isManifestOutput(format) {
+		params := make(map[string]Parameter, len(results))
+		for name, value := range results {
+			params[name] = Parameter{Name: name, Value: value}
+		}
+		result, err := getManifestOutput(params, format, flags)
+		if err != nil {
+			log.Error().Msg(err.Error())
+		}
+		return result, err
+	}
+	if flags.Export && format != OutputEnv {
+		log.Error().Msgf("--export can not be used with %s output", format)
+		return "", fmt.Errorf("export can not be used with %s output", format)
+	}
+	if flags.Nested && format == OutputEnv {
+		log.Error().Msg("--nested can only be used with json or yaml output")
+		return "", errors.New("nested can only be used with json or yaml output")
 	}
 
 	var result string
-	if flags.OutJson {
-		json, _ := json.MarshalIndent(results, "", "  ")
-		result = fmt.Sprint(string(json))
-	} else {
+	switch format {
+	case OutputJson:
+		result, err = getJsonOutput(results, flags)
+	case OutputYaml:
+		result, err = getYamlOutput(results, flags)
+	default:
 		if flags.Export {
 			result = OutputParamsAsString(results, "export ", flags)
 		} else {
 			result = OutputParamsAsString(results, "", flags)
 		}
 	}
-	return result, nil
+	if err != nil {
+		log.Error().Msg(err.Error())
+	}
+	return result, err
 }
@@ -1,11 +1,13 @@
 package util
 
 import (
+	"errors"
 	"fmt"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ssm"
 	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
 	"github.com/rs/zerolog/log"
+	"reflect"
 	"testing"
 )
 
@@ -25,16 +27,6 @@ func nameString(parameter ssm.GetParametersInput) string {
 	return result + "]"
 }
 
-func (sp *MockSSM) GetParameter(input *ssm.GetParameterInput) (*ssm.GetParameterOutput, error) {
-	output := new(ssm.GetParameterOutput)
-	log.Info().Msgf("%s", *input.Name)
-	if *input.Name == "/path2/One1" {
-		name1 := "One1"
-		output.Parameter = &ssm.Parameter{Name: &name1, Value: aws.String("OneVal1")}
-	}
-	return output, sp.err
-}
-
 func (sp *MockSSM) GetParameters(input *ssm.GetParametersInput) (*ssm.GetParametersOutput, error) {
 	output := new(ssm.GetParametersOutput)
 	log.Info().Msgf("%s", nameString(*input))
@@ -58,6 +50,10 @@ func (sp *MockSSM) GetParameters(input *ssm.GetParametersInput) (*ssm.GetParamet
 		output.Parameters = append(output.Parameters, &ssm.Parameter{Name: &name1, Value: aws.String("ThreeVal1")})
 		output.Parameters = append(output.Parameters, &ssm.Parameter{Name: &name2, Value: aws.String("ThreeVal2")})
 	}
+	if nameString(*input) == "[/path2/One1]" {
+		name1 := "/path2/One1"
+		output.Parameters = append(output.Parameters, &ssm.Parameter{Name: &name1, Value: aws.String("OneVal1")})
+	}
 	if nameString(*input) == "[Num0]" {
 		name1 := "Num0"
 		output.Parameters = append(output.Parameters, &ssm.Parameter{Name: &name1, Value: aws.String("0")})
@@ -234,15 +230,8 @@ func Test_ExpandJsonParams(t *testing.T) {
 	}
 	for _, tt := range tests {
 		flags := Flags{
-			false,
-			false,
-			false,
-			tt.upper,
-			false,
-			false,
-			true,
-			false,
-			false,
+			Upper:     tt.upper,
+			Recursive: true,
 		}
 		result, err := ExpandJsonParams(input, flags)
 		if err != nil {
@@ -269,270 +258,145 @@ func Test_GetParams(t *testing.T) {
 		{
 			params: "/path",
 			flags: Flags{
-				false,
-				false,
-				false,
-				true,
-				false,
-				false,
-				true,
-				false,
-				false,
+				Upper:     true,
+				Recursive: true,
 			},
 			want: "ONE1=OneVal1\n",
 		},
 		{
 			params: "/path",
 			flags: Flags{
-				false,
-				false,
-				false,
-				false,
-				false,
-				false,
-				true,
-				false,
-				false,
+				Recursive: true,
 			},
 			want: "One1=OneVal1\n",
 		},
 		{
 			params: "/path,/path2",
 			flags: Flags{
-				false,
-				false,
-				false,
-				true,
-				false,
-				false,
-				true,
-				false,
-				false,
+				Upper:     true,
+				Recursive: true,
 			},
 			want: "ONE1=OneVal1\nONE2=OneVal2\n",
 		},
 		{
 			params: "/path2/One1,/path3",
 			flags: Flags{
-				false,
-				false,
-				false,
-				true,
-				false,
-				false,
-				true,
-				false,
-				false,
+				Upper:     true,
+				Recursive: true,
 			},
 			want: "NAME3=Val3\nNAMESUB=SubVal\nONE1=OneVal1\n",
 		},
 		{
 			params: "One1",
 			flags: Flags{
-				false,
-				false,
-				false,
-				true,
-				false,
-				false,
-				true,
-				false,
-				false,
+				Upper:     true,
+				Recursive: true,
 			},
 			want: "ONE1=OneVal1\n",
 		},
 		{
 			params: "One1,One2",
 			flags: Flags{
-				false,
-				false,
-				false,
-				true,
-				false,
-				false,
-				true,
-				false,
-				false,
+				Upper:     true,
+				Recursive: true,
 			},
 			want: "ONE1=OneVal1\nONE2=OneVal2\n",
 		},
 		{
 			params: "/path,Three1,Three2,/path2",
 			flags: Flags{
-				false,
-				false,
-				false,
-				true,
-				false,
-				false,
-				true,
-				false,
-				false,
+				Upper:     true,
+				Recursive: true,
 			},
 			want: "ONE1=OneVal1\nONE2=OneVal2\nTHREE1=ThreeVal1\nTHREE2=ThreeVal2\n",
 		},
 		{
 			params: "/path,Three1,Three2,/path2",
 			flags: Flags{
-				false,
-				false,
-				false,
-				false,
-				false,
-				false,
-				true,
-				false,
-				false,
+				Recursive: true,
 			},
 			want: "One1=OneVal1\nOne2=OneVal2\nThree1=ThreeVal1\nThree2=ThreeVal2\n",
 		},
 		{
 			params: "/path,Three1,Three2,/path2",
 			flags: Flags{
-				false,
-				false,
-				false,
-				false,
-				true,
-				false,
-				true,
-				false,
-				false,
+				Quote:     true,
+				Recursive: true,
 			},
 			want: "One1=\"OneVal1\"\nOne2=\"OneVal2\"\nThree1=\"ThreeVal1\"\nThree2=\"ThreeVal2\"\n",
 		},
 		{
 			params: "Num0",
 			flags: Flags{
-				false,
-				false,
-				false,
-				true,
-				false,
-				false,
-				true,
-				false,
-				false,
+				Upper:     true,
+				Recursive: true,
 			},
 			want: "NUM0=0\n",
 		},
 		{
 			params: "Json",
 			flags: Flags{
-				false,
-				true,
-				false,
-				true,
-				false,
-				false,
-				true,
-				false,
-				false,
+				InJson:    true,
+				Upper:     true,
+				Recursive: true,
 			},
 			want: "INT=0\nINT123=123\nSTR=0\n",
 		},
 		{
 			params: "Json2",
 			flags: Flags{
-				false,
-				true,
-				false,
-				true,
-				false,
-				false,
-				true,
-				false,
-				false,
+				InJson:    true,
+				Upper:     true,
+				Recursive: true,
 			},
 			want: "BOOL=true\n",
 		},
 		{
 			params: "/path3",
 			flags: Flags{
-				false,
-				false,
-				false,
-				true,
-				false,
-				false,
-				true,
-				false,
-				false,
+				Upper:     true,
+				Recursive: true,
 			},
 			want: "NAME3=Val3\nNAMESUB=SubVal\n",
 		},
 		{
 			params: "/path3",
 			flags: Flags{
-				false,
-				false,
-				false,
-				true,
-				false,
-				false,
-				false,
-				false,
-				false,
+				Upper: true,
 			},
 			want: "NAME3=Val3\n",
 		},
 		{
 			params: "/path3",
 			flags: Flags{
-				false,
-				false,
-				false,
-				false,
-				false,
-				false,
-				true,
-				true,
-				false,
+				Recursive:  true,
+				PrefixPath: true,
 			},
 			want: "/path3/Name3=Val3\n/path3/sub/NameSub=SubVal\n",
 		},
 		{
 			params: "/path3",
 			flags: Flags{
-				false,
-				false,
-				true,
-				false,
-				false,
-				false,
-				true,
-				true,
-				false,
+				OutJson:    true,
+				Recursive:  true,
+				PrefixPath: true,
 			},
 			want: "{\n  \"/path3/Name3\": \"Val3\",\n  \"/path3/sub/NameSub\": \"SubVal\"\n}",
 		},
 		{
 			params: "/path3",
 			flags: Flags{
-				false,
-				false,
-				false,
-				false,
-				false,
-				false,
-				true,
-				false,
-				true,
+				Recursive:            true,
+				PrefixNormalizedPath: true,
 			},
 			want: "path3_Name3=Val3\npath3_sub_NameSub=SubVal\n",
 		},
 		{
 			params: "/path3",
 			flags: Flags{
-				false,
-				false,
-				true,
-				false,
-				false,
-				false,
-				true,
-				false,
-				true,
+				OutJson:              true,
+				Recursive:            true,
+				PrefixNormalizedPath: true,
 			},
 			want: "{\n  \"path3_Name3\": \"Val3\",\n  \"path3_sub_NameSub\": \"SubVal\"\n}",
 		},
@@ -572,15 +436,8 @@ func Test_ReadParametersFromFile(t *testing.T) {
 			fileName: "test.env",
 			basePath: "/saveTest",
 			flags: Flags{
-				false,
-				false,
-				false,
-				false,
-				false,
-				true,
-				true,
-				false,
-				false,
+				Dry:       true,
+				Recursive: true,
 			},
 			want: "One1=Value1\nOne2=Value2\n",
 		},
@@ -608,3 +465,229 @@ func Test_ReadParametersFromFile(t *testing.T) {
 		})
 	}
 }
+
+func Test_NewSSMWithOptions(t *testing.T) {
+	ssmClient := NewSSMWithOptions(SessionOptions{Region: "eu-west-1"})
+	region := *ssmClient.session.Config.Region
+	if region != "eu-west-1" {
+		t.Errorf("Expected region eu-west-1 but got %s", region)
+	}
+}
+
+func Test_ExpandTypedJson(t *testing.T) {
+	value := `{"Plain":"Alice","Typed":{"Value":"a,b","Type":"StringList"},"Untyped":{"Value":"Bob"}}`
+	tests := []struct {
+		name     string
+		want     string
+		wantType string
+	}{
+		{
+			name:     "Plain",
+			want:     "Alice",
+			wantType: "",
+		},
+		{
+			name:     "Typed",
+			want:     "a,b",
+			wantType: "StringList",
+		},
+		{
+			name:     "Untyped",
+			want:     "Bob",
+			wantType: "",
+		},
+	}
+	result, types, err := ExpandTypedJson(value)
+	if err != nil {
+		t.Error("Error expanding typed json")
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result[tt.name] != tt.want {
+				t.Errorf("Expected %s but got %s", tt.want, result[tt.name])
+			}
+			if types[tt.name] != tt.wantType {
+				t.Errorf("Expected type %s but got %s", tt.wantType, types[tt.name])
+			}
+		})
+	}
+}
+
+func Test_SaveParameters(t *testing.T) {
+	tests := []struct {
+		name     string
+		types    map[string]string
+		flags    Flags
+		wantErr  bool
+		wantType map[string]string
+	}{
+		{
+			name:     "keep existing type",
+			flags:    Flags{},
+			wantType: map[string]string{"/app/plain": "String", "/app/list": "StringList", "/app/new": "SecureString"},
+		},
+		{
+			name:     "type flag",
+			flags:    Flags{Type: "String"},
+			wantType: map[string]string{"/app/plain": "String", "/app/list": "String", "/app/new": "String"},
+		},
+		{
+			name:     "type per key",
+			types:    map[string]string{"new": "StringList"},
+			flags:    Flags{Type: "SecureString"},
+			wantType: map[string]string{"/app/plain": "SecureString", "/app/list": "SecureString", "/app/new": "StringList"},
+		},
+		{
+			name:    "invalid type",
+			flags:   Flags{Type: "Number"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memory := newMemorySSM()
+			memory.add("/app/plain", "old", "String", "", "", nil)
+			memory.add("/app/list", "a,b", "StringList", "", "", nil)
+			ssmClient := NewSSM()
+			ssmClient.SSM = memory
+
+			params := map[string]string{"plain": "new", "list": "c,d", "new": "value"}
+			err := ssmClient.SaveParameters(params, tt.types, "/app", tt.flags)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %t but got %v", tt.wantErr, err)
+			}
+			for name, wantType := range tt.wantType {
+				if *memory.params[name].Type != wantType {
+					t.Errorf("Expected %s to be %s but got %s", name, wantType, *memory.params[name].Type)
+				}
+			}
+		})
+	}
+}
+
+func Test_SaveParametersKmsKey(t *testing.T) {
+	kmsKeys := []KmsKeyMapping{
+		{Path: "/prod/*", KeyId: "alias/prod"},
+		{Path: "/prod/payments", KeyId: "alias/payments"},
+	}
+	tests := []struct {
+		name    string
+		path    string
+		flags   Flags
+		wantKey map[string]string
+	}{
+		{
+			name:    "mapped path",
+			path:    "/prod/app",
+			wantKey: map[string]string{"/prod/app/secret": "alias/prod", "/prod/app/plain": ""},
+		},
+		{
+			name:    "longest mapped path",
+			path:    "/prod/payments",
+			wantKey: map[string]string{"/prod/payments/secret": "alias/payments"},
+		},
+		{
+			name:    "unmapped path",
+			path:    "/production",
+			wantKey: map[string]string{"/production/secret": ""},
+		},
+		{
+			name:    "kms flag",
+			path:    "/prod/app",
+			flags:   Flags{KmsKeyId: "alias/other"},
+			wantKey: map[string]string{"/prod/app/secret": "alias/other", "/prod/app/plain": ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memory := newMemorySSM()
+			ssmClient := NewSSM()
+			ssmClient.SSM = memory
+			ssmClient.KmsKeys = kmsKeys
+
+			params := map[string]string{"secret": "value", "plain": "value"}
+			types := map[string]string{"plain": "String"}
+			err := ssmClient.SaveParameters(params, types, tt.path, tt.flags)
+			if err != nil {
+				t.Errorf("Expected no error but got %v", err)
+			}
+			for name, wantKey := range tt.wantKey {
+				if aws.StringValue(memory.params[name].KeyId) != wantKey {
+					t.Errorf("Expected %s to use key %q but got %q", name, wantKey, aws.StringValue(memory.params[name].KeyId))
+				}
+			}
+		})
+	}
+}
+
+func Test_SplitSelector(t *testing.T) {
+	tests := []struct {
+		param        string
+		wantName     string
+		wantSelector string
+	}{
+		{"name", "name", ""},
+		{"name:3", "name", "3"},
+		{"/path/name:prod", "/path/name", "prod"},
+		{"/path:release-42", "/path", "release-42"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.param, func(t *testing.T) {
+			name, selector := SplitSelector(tt.param)
+			if name != tt.wantName || selector != tt.wantSelector {
+				t.Errorf("Expected %s and %s but got %s and %s", tt.wantName, tt.wantSelector, name, selector)
+			}
+		})
+	}
+}
+
+func Test_GetParamsWithSelectors(t *testing.T) {
+	tests := []struct {
+		params  string
+		flags   Flags
+		want    map[string]string
+		wantErr error
+	}{
+		{
+			params: "/app/db_password:1",
+			want:   map[string]string{"db_password": "first"},
+		},
+		{
+			params: "/app/db_password:prod,/app/db_user:1",
+			flags:  Flags{PrefixPath: true},
+			want:   map[string]string{"/app/db_password": "second", "/app/db_user": "admin"},
+		},
+		{
+			params: "/app:stable",
+			flags:  Flags{Recursive: true, Upper: true},
+			want:   map[string]string{"DB_PASSWORD": "second"},
+		},
+		{
+			params: "/app",
+			flags:  Flags{Recursive: true, PrefixNormalizedPath: true},
+			want:   map[string]string{"app_db_password": "third", "app_db_user": "admin"},
+		},
+		{
+			params:  "/app:3",
+			flags:   Flags{Recursive: true},
+			wantErr: ErrVersionOnPath,
+		},
+		{
+			params:  "/app:missing",
+			flags:   Flags{Recursive: true},
+			wantErr: ErrNameNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.params, func(t *testing.T) {
+			ssmClient, _ := newHistoryTestSSM()
+			got, err := ssmClient.GetParams(&tt.params, tt.flags)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected error %v but got %v", tt.wantErr, err)
+			}
+			if tt.wantErr == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v but got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package util
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zalando/go-keyring"
+)
+
+const (
+	cacheSuffix     = ".cache"
+	cacheKeySize    = 32
+	cacheSaltFile   = "salt"
+	cacheIterations = 600000
+	keyringUser     = "cache-key"
+)
+
+var ErrCacheKey = errors.New("Invalid cache key")
+
+// Cache stores results of GetParamsDetailed encrypted on disk for a TTL
+type Cache struct {
+	Dir string
+	TTL time.Duration
+	// Refresh does not read from the cache, but still stores fresh results
+	Refresh bool
+	key     []byte
+}
+
+// cacheEntry is the encrypted content of a cache file
+type cacheEntry struct {
+	Created time.Time            `json:"created"`
+	Params  map[string]Parameter `json:"params"`
+}
+
+// cacheQuery is everything a result depends on, hashed to the name of the cache file
+type cacheQuery struct {
+	Scope                string            `json:"scope"`
+	Query                string            `json:"query"`
+	InJson               bool              `json:"injson"`
+	Upper                bool              `json:"upper"`
+	Recursive            bool              `json:"recursive"`
+	PrefixPath           bool              `json:"prefixpath"`
+	PrefixNormalizedPath bool              `json:"prefixnormalizedpath"`
+	Tags                 map[string]string `json:"tags"`
+	SecretsManager       bool              `json:"secretsmanager"`
+}
+
+// NewCache creates a cache in the directory, encrypting with a 32 byte key
+func NewCache(dir string, ttl time.Duration, key []byte) (*Cache, error) {
+	if len(key) != cacheKeySize {
+		return nil, fmt.Errorf("%w: need %d bytes", ErrCacheKey, cacheKeySize)
+	}
+	return &Cache{Dir: dir, TTL: ttl, key: key}, nil
+}
+
+// CacheKeyFromKeyring reads the cache key from the OS keyring, a new random key is stored on first use
+func CacheKeyFromKeyring(service string) ([]byte, error) {
+	encoded, err := keyring.Get(service, keyringUser)
+	if errors.Is(err, keyring.ErrNotFound) {
+		key := make([]byte, cacheKeySize)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		encoded = base64.StdEncoding.EncodeToString(key)
+		if err := keyring.Set(service, keyringUser, encoded); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != cacheKeySize {
+		return nil, fmt.Errorf("%w: in keyring %s", ErrCacheKey, service)
+	}
+	return key, nil
+}
+
+// CacheKeyFromPassphraseFile derives the cache key from the first line of a file,
+// with a random salt which is stored in the cache directory on first use
+func CacheKeyFromPassphraseFile(fileName string, dir string) ([]byte, error) {
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	passphrase := strings.TrimRight(strings.SplitN(string(content), "\n", 2)[0], "\r")
+	if passphrase == "" {
+		return nil, fmt.Errorf("%w: empty passphrase in %s", ErrCacheKey, fileName)
+	}
+	salt, err := cacheSalt(dir)
+	if err != nil {
+		return nil, err
+	}
+	return pbkdf2.Key(sha256.New, passphrase, salt, cacheIterations, cacheKeySize)
+}
+
+func cacheSalt(dir string) ([]byte, error) {
+	saltFile := filepath.Join(dir, cacheSaltFile)
+	salt, err := os.ReadFile(saltFile)
+	if err == nil {
+		return salt, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	salt = make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return salt, writeFileAtomic(saltFile, salt)
+}
+
+// cacheName hashes the query, so the file names do not reveal parameter names
+func cacheName(scope string, paramstring string, flags Flags) string {
+	query, _ := json.Marshal(cacheQuery{
+		Scope:                scope,
+		Query:                paramstring,
+		InJson:               flags.InJson,
+		Upper:                flags.Upper,
+		Recursive:            flags.Recursive,
+		PrefixPath:           flags.PrefixPath,
+		PrefixNormalizedPath: flags.PrefixNormalizedPath,
+		Tags:                 flags.Tags,
+		SecretsManager:       flags.SecretsManager,
+	})
+	hash := sha256.Sum256(query)
+	return hex.EncodeToString(hash[:])
+}
+
+func (c *Cache) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(c.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Get returns the results of a cache file which is not expired. Files which can not be read are removed.
+func (c *Cache) Get(name string) (map[string]Parameter, bool) {
+	if c.Refresh {
+		return nil, false
+	}
+	fileName := filepath.Join(c.Dir, name+cacheSuffix)
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, false
+	}
+	entry, err := c.decrypt(name, content)
+	if err != nil {
+		log.Warn().Msgf("Removing unreadable cache entry: %s", err.Error())
+		_ = os.Remove(fileName)
+		return nil, false
+	}
+	if now().Sub(entry.Created) > c.TTL {
+		log.Debug().Msgf("Cache entry expired: %s", name)
+		_ = os.Remove(fileName)
+		return nil, false
+	}
+	log.Debug().Msgf("Read from cache: %s", name)
+	return entry.Params, true
+}
+
+func (c *Cache) decrypt(name string, content []byte) (cacheEntry, error) {
+	var entry cacheEntry
+	aead, err := c.aead()
+	if err != nil {
+		return entry, err
+	}
+	if len(content) < aead.NonceSize() {
+		return entry, fmt.Errorf("%w: entry too short", ErrCacheKey)
+	}
+	// the name is authenticated, so entries can not be swapped
+	plain, err := aead.Open(nil, content[:aead.NonceSize()], content[aead.NonceSize():], []byte(name))
+	if err != nil {
+		return entry, err
+	}
+	err = json.Unmarshal(plain, &entry)
+	return entry, err
+}
+
+// Put stores the results encrypted, readable only by the user
+func (c *Cache) Put(name string, params map[string]Parameter) error {
+	plain, err := json.Marshal(cacheEntry{Created: now(), Params: params})
+	if err != nil {
+		return err
+	}
+	aead, err := c.aead()
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.Dir, 0700); err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(c.Dir, name+cacheSuffix), aead.Seal(nonce, nonce, plain, []byte(name)))
+}
+
+// Clear removes all cache entries, the salt of a passphrase is kept
+func (c *Cache) Clear() error {
+	return ClearCache(c.Dir)
+}
+
+// clearCache removes the cached results after a write, as they may be outdated
+func (f *AWSSSM) clearCache() {
+	if f.Cache == nil {
+		return
+	}
+	if err := f.Cache.Clear(); err != nil {
+		log.Warn().Msgf("Failed to clear cache: %s", err.Error())
+	}
+}
+
+// ClearCache removes all cache entries of a directory, it does not need the key
+func ClearCache(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*"+cacheSuffix))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFileAtomic writes to a temporary file and renames it, so readers never see a partial file
+func writeFileAtomic(fileName string, content []byte) error {
+	temp, err := os.CreateTemp(filepath.Dir(fileName), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.Write(content); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), fileName)
+}
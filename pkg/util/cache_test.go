@@ -0,0 +1,187 @@
+package util
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTestCache(t *testing.T, dir string, key byte) *Cache {
+	cache, err := NewCache(dir, time.Minute, bytes.Repeat([]byte{key}, cacheKeySize))
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	return cache
+}
+
+func Test_Cache(t *testing.T) {
+	fakeClock(t)
+	params := map[string]Parameter{"NAME": {Name: "/app/name", Value: "secret", Type: "SecureString"}}
+
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T, dir string, cache *Cache)
+		wantHit bool
+	}{
+		{name: "hit", wantHit: true},
+		{
+			name: "expired",
+			prepare: func(t *testing.T, dir string, cache *Cache) {
+				sleep(2 * time.Minute)
+			},
+		},
+		{
+			name: "other key",
+			prepare: func(t *testing.T, dir string, cache *Cache) {
+				cache.key = bytes.Repeat([]byte{2}, cacheKeySize)
+			},
+		},
+		{
+			name: "swapped entry",
+			prepare: func(t *testing.T, dir string, cache *Cache) {
+				if err := cache.Put("other", params); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Rename(filepath.Join(dir, "other.cache"), filepath.Join(dir, "entry.cache")); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "refresh",
+			prepare: func(t *testing.T, dir string, cache *Cache) {
+				cache.Refresh = true
+			},
+		},
+		{
+			name: "cleared",
+			prepare: func(t *testing.T, dir string, cache *Cache) {
+				if err := cache.Clear(); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			cache := newTestCache(t, dir, 1)
+			if err := cache.Put("entry", params); err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			content, _ := os.ReadFile(filepath.Join(dir, "entry.cache"))
+			if bytes.Contains(content, []byte("secret")) || bytes.Contains(content, []byte("/app/name")) {
+				t.Fatalf("Expected the entry to be encrypted")
+			}
+			if tt.prepare != nil {
+				tt.prepare(t, dir, cache)
+			}
+			got, ok := cache.Get("entry")
+			if ok != tt.wantHit {
+				t.Fatalf("Expected hit %t but got %t", tt.wantHit, ok)
+			}
+			if ok && !reflect.DeepEqual(got, params) {
+				t.Errorf("Get() = %v, want %v", got, params)
+			}
+		})
+	}
+
+	if _, err := NewCache(t.TempDir(), time.Minute, []byte("short")); !errors.Is(err, ErrCacheKey) {
+		t.Errorf("Expected ErrCacheKey for a short key but got %v", err)
+	}
+}
+
+func Test_CacheKeyFromPassphraseFile(t *testing.T) {
+	dir := t.TempDir()
+	passphraseFile := filepath.Join(t.TempDir(), "passphrase")
+	if err := os.WriteFile(passphraseFile, []byte("correct horse\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	key, err := CacheKeyFromPassphraseFile(passphraseFile, dir)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(key) != cacheKeySize {
+		t.Errorf("Expected a key of %d bytes but got %d", cacheKeySize, len(key))
+	}
+	again, _ := CacheKeyFromPassphraseFile(passphraseFile, dir)
+	if !bytes.Equal(key, again) {
+		t.Errorf("Expected the same key with the stored salt")
+	}
+	other, _ := CacheKeyFromPassphraseFile(passphraseFile, t.TempDir())
+	if bytes.Equal(key, other) {
+		t.Errorf("Expected another key with another salt")
+	}
+
+	if err := ClearCache(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, cacheSaltFile)); err != nil {
+		t.Errorf("Expected the salt to be kept when clearing: %v", err)
+	}
+
+	emptyFile := filepath.Join(t.TempDir(), "empty")
+	if err := os.WriteFile(emptyFile, []byte("\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CacheKeyFromPassphraseFile(emptyFile, dir); !errors.Is(err, ErrCacheKey) {
+		t.Errorf("Expected ErrCacheKey for an empty passphrase but got %v", err)
+	}
+}
+
+func Test_GetParamsDetailedCached(t *testing.T) {
+	memory := newMemorySSM()
+	memory.add("/app/name1", "value1", "String", "", "", nil)
+	memory.add("/app/name2", "value2", "String", "", "", nil)
+	memory.add("single", "value3", "String", "", "", nil)
+	ssmClient := &AWSSSM{SSM: memory, Cache: newTestCache(t, t.TempDir(), 1), scope: "default|eu-central-1|"}
+
+	paramstring := "/app,single"
+	flags := Flags{Recursive: true}
+	first, err := ssmClient.GetParamsDetailed(&paramstring, flags)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	calls := memory.getCalls
+	second, err := ssmClient.GetParamsDetailed(&paramstring, flags)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if memory.getCalls != calls {
+		t.Errorf("Expected the second read from the cache")
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Expected the cached result %v but got %v", first, second)
+	}
+
+	if _, err := ssmClient.GetParamsDetailed(&paramstring, Flags{Recursive: true, Upper: true}); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if memory.getCalls == calls {
+		t.Errorf("Expected other flags not to be read from the cache")
+	}
+
+	if err := ssmClient.SaveParameter("single", "changed", ""); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	params, err := ssmClient.GetParams(&paramstring, flags)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if params["single"] != "changed" {
+		t.Errorf("Expected a save to clear the cache but got %s", params["single"])
+	}
+
+	ssmClient.scope = "other|eu-central-1|"
+	calls = memory.getCalls
+	if _, err := ssmClient.GetParamsDetailed(&paramstring, flags); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if memory.getCalls == calls {
+		t.Errorf("Expected another profile not to be read from the cache")
+	}
+}
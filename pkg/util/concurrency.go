@@ -0,0 +1,62 @@
+package util
+
+import "sync"
+
+// runConcurrently calls work for the indexes 0 to count-1 with at most concurrency calls at a time.
+// Indexes are started in order and no new ones after an error, so like a serial loop the error of the lowest index is returned.
+func runConcurrently(count int, concurrency int, work func(index int) error) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if concurrency > count {
+		concurrency = count
+	}
+	errs := make([]error, count)
+	indexes := make(chan int)
+	var failed bool
+	var mutex sync.Mutex
+	var group sync.WaitGroup
+	for worker := 0; worker < concurrency; worker++ {
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			for index := range indexes {
+				if err := work(index); err != nil {
+					mutex.Lock()
+					failed = true
+					mutex.Unlock()
+					errs[index] = err
+				}
+			}
+		}()
+	}
+	for index := 0; index < count; index++ {
+		mutex.Lock()
+		stop := failed
+		mutex.Unlock()
+		if stop {
+			break
+		}
+		indexes <- index
+	}
+	close(indexes)
+	group.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeParameters merges the results in order, so names of later results override earlier ones
+func mergeParameters(results []map[string]Parameter) map[string]Parameter {
+	params := make(map[string]Parameter)
+	for _, result := range results {
+		for name, param := range result {
+			params[name] = param
+		}
+	}
+	return params
+}
@@ -0,0 +1,139 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+func Test_runConcurrently(t *testing.T) {
+	errFirst := errors.New("first")
+	errSecond := errors.New("second")
+	tests := []struct {
+		name        string
+		count       int
+		concurrency int
+		failing     map[int]error
+		wantErr     error
+	}{
+		{name: "serial", count: 5, concurrency: 1},
+		{name: "zero is serial", count: 5, concurrency: 0},
+		{name: "more workers than work", count: 3, concurrency: 10},
+		{name: "nothing to do", count: 0, concurrency: 4},
+		{name: "lowest index error", count: 8, concurrency: 4, failing: map[int]error{1: errFirst, 2: errSecond}, wantErr: errFirst},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mutex sync.Mutex
+			running, maxRunning, calls := 0, 0, 0
+			err := runConcurrently(tt.count, tt.concurrency, func(index int) error {
+				mutex.Lock()
+				calls++
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				mutex.Unlock()
+				// later indexes finish first
+				time.Sleep(time.Duration(tt.count-index) * time.Millisecond)
+				mutex.Lock()
+				running--
+				mutex.Unlock()
+				return tt.failing[index]
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("runConcurrently() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && calls != tt.count {
+				t.Errorf("Expected %d calls but got %d", tt.count, calls)
+			}
+			if tt.concurrency > 1 && maxRunning > tt.concurrency || tt.concurrency <= 1 && maxRunning > 1 {
+				t.Errorf("Expected at most %d at a time but got %d", tt.concurrency, maxRunning)
+			}
+		})
+	}
+}
+
+// delayedSSM answers earlier paths later, so concurrent reads finish in reverse order
+type delayedSSM struct {
+	*memorySSM
+}
+
+func (d *delayedSSM) GetParametersByPath(input *ssm.GetParametersByPathInput) (*ssm.GetParametersByPathOutput, error) {
+	index, _ := strconv.Atoi(strings.TrimPrefix(*input.Path, "/p"))
+	time.Sleep(time.Duration(10-index) * 2 * time.Millisecond)
+	return d.memorySSM.GetParametersByPath(input)
+}
+
+func Test_GetParamsConcurrently(t *testing.T) {
+	memory := newMemorySSM()
+	paths := make([]string, 0)
+	for index := 1; index <= 8; index++ {
+		path := fmt.Sprintf("/p%d", index)
+		paths = append(paths, path)
+		memory.add(path+"/key", path, "String", "", "", nil)
+		memory.add(fmt.Sprintf("%s/only%d", path, index), path, "String", "", "", nil)
+	}
+	names := make([]string, 0)
+	for index := 1; index <= 25; index++ {
+		name := fmt.Sprintf("name%02d", index)
+		names = append(names, name)
+		memory.add(name, name, "String", "", "", nil)
+	}
+	ssmClient := NewSSM()
+	ssmClient.SSM = &delayedSSM{memory}
+
+	tests := []struct {
+		name        string
+		paramstring string
+		wantKey     string
+	}{
+		{name: "last path wins", paramstring: strings.Join(paths, ","), wantKey: "/p8"},
+		{name: "last path wins reversed", paramstring: "/p8,/p7,/p6,/p5,/p4,/p3,/p2,/p1", wantKey: "/p1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, concurrency := range []int{1, 4, 8} {
+				params, err := ssmClient.GetParams(&tt.paramstring, Flags{Recursive: true, Concurrency: concurrency})
+				if err != nil {
+					t.Fatalf("Expected no error but got %v", err)
+				}
+				if params["key"] != tt.wantKey {
+					t.Errorf("Concurrency %d: expected key from %s but got %s", concurrency, tt.wantKey, params["key"])
+				}
+				if len(params) != 9 {
+					t.Errorf("Concurrency %d: expected 9 keys but got %d", concurrency, len(params))
+				}
+			}
+		})
+	}
+
+	t.Run("names in chunks", func(t *testing.T) {
+		memory.getCalls = 0
+		paramstring := strings.Join(names, ",")
+		params, err := ssmClient.GetParams(&paramstring, Flags{Concurrency: 3})
+		if err != nil {
+			t.Fatalf("Expected no error but got %v", err)
+		}
+		if len(params) != 25 || params["name25"] != "name25" {
+			t.Errorf("Expected all 25 names but got %v", params)
+		}
+		if memory.getCalls != 3 {
+			t.Errorf("Expected 3 chunks but got %d calls", memory.getCalls)
+		}
+	})
+
+	t.Run("error of the first failing path", func(t *testing.T) {
+		paramstring := "/p1,/missing,/p3:7"
+		_, err := ssmClient.GetParams(&paramstring, Flags{Recursive: true, Concurrency: 4})
+		if !errors.Is(err, ErrNameNotFound) {
+			t.Errorf("Expected ErrNameNotFound but got %v", err)
+		}
+	})
+}
@@ -0,0 +1,253 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/rs/zerolog/log"
+)
+
+var ErrNoParametersOnPath = errors.New("No parameters found on path")
+
+type CopyOptions struct {
+	Recursive bool
+	Overwrite bool
+	Dry       bool
+	// KeyId replaces the kms key of SecureStrings, as keys of the source are not usable in another account or region
+	KeyId string
+}
+
+// ParameterDetails is a parameter with all attributes needed to recreate it on another name
+type ParameterDetails struct {
+	Name           string
+	Value          string
+	Type           string
+	DataType       string
+	KeyId          string
+	Description    string
+	Tier           string
+	AllowedPattern string
+	Tags           map[string]string
+}
+
+// GetParameterDetailsByPath reads all parameters under a path with their metadata and tags, sorted by name
+func (f *AWSSSM) GetParameterDetailsByPath(path string, recursive bool) ([]ParameterDetails, error) {
+	if err := f.requireSSM(); err != nil {
+		return nil, err
+	}
+	details := make(map[string]*ParameterDetails)
+
+	var nextToken *string
+	for {
+		input := &ssm.GetParametersByPathInput{
+			Path:           aws.String(path),
+			Recursive:      aws.Bool(recursive),
+			WithDecryption: &trueBool,
+			NextToken:      nextToken,
+		}
+		var output *ssm.GetParametersByPathOutput
+		err := f.read("GetParametersByPath", func() (err error) {
+			output, err = f.SSM.GetParametersByPath(input)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, param := range output.Parameters {
+			details[*param.Name] = &ParameterDetails{
+				Name:     aws.StringValue(param.Name),
+				Value:    aws.StringValue(param.Value),
+				Type:     aws.StringValue(param.Type),
+				DataType: aws.StringValue(param.DataType),
+			}
+		}
+		nextToken = output.NextToken
+		if nextToken == nil {
+			break
+		}
+	}
+	if len(details) == 0 {
+		log.Error().Msgf("No names found for path: %s", path)
+		return nil, ErrNoParametersOnPath
+	}
+
+	// the metadata is only returned by DescribeParameters
+	option := "OneLevel"
+	if recursive {
+		option = "Recursive"
+	}
+	nextToken = nil
+	for {
+		input := &ssm.DescribeParametersInput{
+			ParameterFilters: []*ssm.ParameterStringFilter{
+				{
+					Key:    aws.String("Path"),
+					Option: aws.String(option),
+					Values: []*string{aws.String(path)},
+				},
+			},
+			NextToken: nextToken,
+		}
+		var output *ssm.DescribeParametersOutput
+		err := f.read("DescribeParameters", func() (err error) {
+			output, err = f.SSM.DescribeParameters(input)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, meta := range output.Parameters {
+			detail, ok := details[*meta.Name]
+			if !ok {
+				continue
+			}
+			detail.KeyId = aws.StringValue(meta.KeyId)
+			detail.Description = aws.StringValue(meta.Description)
+			detail.Tier = aws.StringValue(meta.Tier)
+			detail.AllowedPattern = aws.StringValue(meta.AllowedPattern)
+		}
+		nextToken = output.NextToken
+		if nextToken == nil {
+			break
+		}
+	}
+
+	names := make([]string, 0, len(details))
+	for name := range details {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]ParameterDetails, 0, len(names))
+	for _, name := range names {
+		tags, err := f.GetTags(name)
+		if err != nil {
+			return nil, err
+		}
+		details[name].Tags = tags
+		result = append(result, *details[name])
+	}
+	return result, nil
+}
+
+// GetTags returns the tags of a parameter
+func (f *AWSSSM) GetTags(name string) (map[string]string, error) {
+	if err := f.requireSSM(); err != nil {
+		return nil, err
+	}
+	input := &ssm.ListTagsForResourceInput{
+		ResourceType: aws.String(ssm.ResourceTypeForTaggingParameter),
+		ResourceId:   aws.String(name),
+	}
+	var output *ssm.ListTagsForResourceOutput
+	err := f.read("ListTagsForResource", func() (err error) {
+		output, err = f.SSM.ListTagsForResource(input)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[string]string)
+	for _, tag := range output.TagList {
+		tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+	return tags, nil
+}
+
+// AddTags adds or replaces tags on a parameter
+func (f *AWSSSM) AddTags(name string, tags map[string]string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	if err := f.requireSSM(); err != nil {
+		return err
+	}
+	input := &ssm.AddTagsToResourceInput{
+		ResourceType: aws.String(ssm.ResourceTypeForTaggingParameter),
+		ResourceId:   aws.String(name),
+	}
+	for _, key := range GetSortedNamesFromParams(tags) {
+		input.Tags = append(input.Tags, &ssm.Tag{Key: aws.String(key), Value: aws.String(tags[key])})
+	}
+	return f.write("AddTagsToResource", func() error {
+		_, err := f.SSM.AddTagsToResource(input)
+		return err
+	})
+}
+
+// PutParameterDetails writes a parameter with its metadata and tags to the store.
+// Returns false, if the parameter exists and overwrite is false.
+func (f *AWSSSM) PutParameterDetails(param ParameterDetails, overwrite bool) (bool, error) {
+	written, err := f.store().Put(param, overwrite)
+	if written {
+		f.clearCache()
+	}
+	return written, err
+}
+
+// RewritePath replaces the source path prefix of a name with the target path
+func RewritePath(name string, sourcePath string, targetPath string) string {
+	relative := strings.TrimPrefix(name, strings.TrimSuffix(sourcePath, "/"))
+	return strings.TrimSuffix(targetPath, "/") + relative
+}
+
+// CopyParameters copies all parameters under the source path to the target path of the target ssm,
+// keeping type, kms key, description and tags
+func (f *AWSSSM) CopyParameters(target *AWSSSM, sourcePath string, targetPath string, options CopyOptions) error {
+	for _, path := range []string{sourcePath, targetPath} {
+		isPath, err := IsPath(&path)
+		if err != nil {
+			return err
+		}
+		if !isPath {
+			log.Error().Msgf("Not a path: %s", path)
+			return errors.New("Not a path")
+		}
+	}
+
+	params, err := f.GetParameterDetailsByPath(sourcePath, options.Recursive)
+	if err != nil {
+		log.Error().Msg(err.Error())
+		return err
+	}
+
+	if options.Dry {
+		fmt.Println("### Dry run, not copying, this would have been copied:")
+	}
+	for _, param := range params {
+		sourceName := param.Name
+		param.Name = RewritePath(param.Name, sourcePath, targetPath)
+		if options.KeyId != "" && param.Type == ssm.ParameterTypeSecureString {
+			param.KeyId = options.KeyId
+		}
+		fmt.Printf("%s -> %s (%s)\n", sourceName, param.Name, describeParameter(param))
+		if options.Dry {
+			continue
+		}
+		written, err := target.PutParameterDetails(param, options.Overwrite)
+		if err != nil {
+			log.Error().Msgf("Error copying %s: %s", sourceName, err.Error())
+			return err
+		}
+		if !written {
+			log.Warn().Msgf("Not overwriting existing parameter: %s", param.Name)
+		}
+	}
+	return nil
+}
+
+// describeParameter summarizes the attributes of a parameter for the output
+func describeParameter(param ParameterDetails) string {
+	attributes := []string{param.Type}
+	if param.KeyId != "" {
+		attributes = append(attributes, "kms="+param.KeyId)
+	}
+	for _, key := range GetSortedNamesFromParams(param.Tags) {
+		attributes = append(attributes, fmt.Sprintf("tag:%s=%s", key, param.Tags[key]))
+	}
+	return strings.Join(attributes, ", ")
+}
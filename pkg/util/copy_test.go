@@ -0,0 +1,137 @@
+package util
+
+import (
+	"testing"
+)
+
+func newCopyTestSSM() *memorySSM {
+	memory := newMemorySSM()
+	memory.add("/staging/app/db_host", "staging-db", "String", "", "Database host", map[string]string{"team": "payments"})
+	memory.add("/staging/app/db_password", "secret", "SecureString", "alias/staging", "", nil)
+	memory.add("/staging/app/sub/list", "a,b", "StringList", "", "", nil)
+	memory.add("/prod/app/db_host", "prod-db", "String", "", "", nil)
+	return memory
+}
+
+func Test_RewritePath(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		target string
+		want   string
+	}{
+		{"/staging/app/db_host", "/staging/app", "/prod/app", "/prod/app/db_host"},
+		{"/staging/app/sub/list", "/staging/app/", "/prod/app/", "/prod/app/sub/list"},
+		{"/staging/app/db_host", "/staging", "/prod/other", "/prod/other/app/db_host"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := RewritePath(tt.name, tt.source, tt.target)
+			if result != tt.want {
+				t.Errorf("Expected %s but got %s", tt.want, result)
+			}
+		})
+	}
+}
+
+func Test_CopyParameters(t *testing.T) {
+	tests := []struct {
+		name    string
+		options CopyOptions
+		want    map[string]string
+	}{
+		{
+			name:    "overwrite",
+			options: CopyOptions{Overwrite: true},
+			want:    map[string]string{"/prod/app/db_host": "staging-db", "/prod/app/db_password": "secret"},
+		},
+		{
+			name:    "no overwrite",
+			options: CopyOptions{},
+			want:    map[string]string{"/prod/app/db_host": "prod-db", "/prod/app/db_password": "secret"},
+		},
+		{
+			name:    "recursive",
+			options: CopyOptions{Recursive: true, Overwrite: true},
+			want:    map[string]string{"/prod/app/db_host": "staging-db", "/prod/app/sub/list": "a,b"},
+		},
+		{
+			name:    "dry",
+			options: CopyOptions{Dry: true, Overwrite: true},
+			want:    map[string]string{"/prod/app/db_host": "prod-db", "/prod/app/db_password": ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memory := newCopyTestSSM()
+			ssmClient := NewSSM()
+			ssmClient.SSM = memory
+			err := ssmClient.CopyParameters(ssmClient, "/staging/app", "/prod/app", tt.options)
+			if err != nil {
+				t.Errorf("Error in CopyParameters: %s", err)
+			}
+			for name, want := range tt.want {
+				if memory.values[name] != want {
+					t.Errorf("Expected %s=%s but got %s", name, want, memory.values[name])
+				}
+			}
+		})
+	}
+}
+
+func Test_CopyParametersKeepsAttributes(t *testing.T) {
+	memory := newCopyTestSSM()
+	ssmClient := NewSSM()
+	ssmClient.SSM = memory
+	err := ssmClient.CopyParameters(ssmClient, "/staging/app", "/prod/app", CopyOptions{Overwrite: true})
+	if err != nil {
+		t.Errorf("Error in CopyParameters: %s", err)
+	}
+
+	host := memory.params["/prod/app/db_host"]
+	if *host.Type != "String" || *host.Description != "Database host" {
+		t.Errorf("Expected String with description but got %s", host)
+	}
+	if memory.tags["/prod/app/db_host"]["team"] != "payments" {
+		t.Errorf("Expected tag team=payments but got %s", memory.tags["/prod/app/db_host"])
+	}
+	password := memory.params["/prod/app/db_password"]
+	if *password.Type != "SecureString" || *password.KeyId != "alias/staging" {
+		t.Errorf("Expected SecureString with kms key but got %s", password)
+	}
+}
+
+func Test_CopyParametersNotFound(t *testing.T) {
+	ssmClient := NewSSM()
+	ssmClient.SSM = newCopyTestSSM()
+	err := ssmClient.CopyParameters(ssmClient, "/missing", "/prod/app", CopyOptions{})
+	if err != ErrNoParametersOnPath {
+		t.Errorf("Expected ErrNoParametersOnPath but got %v", err)
+	}
+}
+
+func Test_CopyParametersToOtherTarget(t *testing.T) {
+	source := newCopyTestSSM()
+	target := newMemorySSM()
+	sourceClient := NewSSM()
+	sourceClient.SSM = source
+	targetClient := NewSSM()
+	targetClient.SSM = target
+
+	err := sourceClient.CopyParameters(targetClient, "/staging/app", "/prod/app", CopyOptions{Overwrite: true, KeyId: "alias/prod"})
+	if err != nil {
+		t.Errorf("Error in CopyParameters: %s", err)
+	}
+	if len(target.values) != 2 {
+		t.Errorf("Expected 2 parameters on the target but got %d", len(target.values))
+	}
+	if _, ok := source.values["/prod/app/db_password"]; ok {
+		t.Error("Expected nothing written to the source")
+	}
+	if *target.params["/prod/app/db_password"].KeyId != "alias/prod" {
+		t.Errorf("Expected kms key alias/prod but got %s", *target.params["/prod/app/db_password"].KeyId)
+	}
+	if target.params["/prod/app/db_host"].KeyId != nil {
+		t.Errorf("Expected no kms key for String but got %s", *target.params["/prod/app/db_host"].KeyId)
+	}
+}
@@ -0,0 +1,94 @@
+package util
+
+import (
+	"sort"
+)
+
+// ResolveParameterNames returns the sorted full names of all parameters of a list of names and paths, as read by GetParams.
+// Paths are listed without reading the values, names with selectors or tags are read. Secrets are rejected.
+func (f *AWSSSM) ResolveParameterNames(paramstring *string, flags Flags) ([]string, error) {
+	params := SplitParams(paramstring)
+	if err := rejectSecrets(params); err != nil {
+		return nil, err
+	}
+	if len(flags.Tags) > 0 || hasSelector(params) {
+		return f.readParameterNames(paramstring, flags)
+	}
+
+	found := make(map[string]bool)
+	singles := make([]*string, 0)
+	for index := range params {
+		isPath, err := IsPath(&params[index])
+		if err != nil {
+			return nil, err
+		}
+		if isPath {
+			listed, err := f.store().List(params[index], flags.Recursive)
+			if err != nil {
+				return nil, err
+			}
+			for _, name := range listed {
+				found[name] = true
+			}
+			if len(listed) > 0 {
+				continue
+			}
+		}
+		// a path without parameters below is read as a single name, like GetParams does
+		singles = append(singles, &params[index])
+	}
+	if len(singles) > 0 {
+		singleParams, err := f.GetParameters(singles, Flags{PrefixPath: true, Concurrency: flags.Concurrency})
+		if err != nil {
+			return nil, err
+		}
+		for _, param := range singleParams {
+			found[param.Name] = true
+		}
+	}
+	return sortedKeys(found), nil
+}
+
+// readParameterNames resolves the names by reading the parameters with GetParamsDetailed
+func (f *AWSSSM) readParameterNames(paramstring *string, flags Flags) ([]string, error) {
+	resolveFlags := Flags{
+		Recursive:   flags.Recursive,
+		PrefixPath:  true,
+		Concurrency: flags.Concurrency,
+		Tags:        flags.Tags,
+	}
+	params, err := f.GetParamsDetailed(paramstring, resolveFlags)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]bool, len(params))
+	for _, param := range params {
+		found[param.Name] = true
+	}
+	return sortedKeys(found), nil
+}
+
+func hasSelector(params []string) bool {
+	for _, param := range params {
+		if _, selector := SplitSelector(param); selector != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(values map[string]bool) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// DeleteParameters deletes the parameters from the store, names which do not exist are ignored
+func (f *AWSSSM) DeleteParameters(names []string) error {
+	err := f.store().Delete(names)
+	f.clearCache()
+	return err
+}
@@ -0,0 +1,70 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func newDeleteTestSSM() *memorySSM {
+	memory := newMemorySSM()
+	for i := 0; i < 12; i++ {
+		memory.add(fmt.Sprintf("/old/app/name%02d", i), "value", "String", "", "", nil)
+	}
+	memory.add("/old/app/sub/nested", "value", "String", "", "", nil)
+	memory.add("single", "value", "String", "", "", nil)
+	memory.add("/keep/name", "value", "String", "", "", nil)
+	return memory
+}
+
+func Test_ResolveParameterNames(t *testing.T) {
+	tests := []struct {
+		params    string
+		recursive bool
+		want      int
+	}{
+		{"/old/app", true, 13},
+		{"/old/app", false, 12},
+		{"/old/app/sub,single", true, 2},
+		{"/old/app/name01", true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.params, func(t *testing.T) {
+			ssmClient := NewSSM()
+			ssmClient.SSM = newDeleteTestSSM()
+			names, err := ssmClient.ResolveParameterNames(&tt.params, Flags{Recursive: tt.recursive})
+			if err != nil {
+				t.Errorf("Error in ResolveParameterNames: %s", err)
+			}
+			if len(names) != tt.want {
+				t.Errorf("Expected %d names but got %d: %s", tt.want, len(names), names)
+			}
+			for _, name := range names {
+				if name != "single" && !strings.HasPrefix(name, "/old/app") {
+					t.Errorf("Unexpected name %s", name)
+				}
+			}
+		})
+	}
+}
+
+func Test_DeleteParameters(t *testing.T) {
+	memory := newDeleteTestSSM()
+	ssmClient := NewSSM()
+	ssmClient.SSM = memory
+	params := "/old/app,single"
+	names, err := ssmClient.ResolveParameterNames(&params, Flags{Recursive: true})
+	if err != nil {
+		t.Errorf("Error in ResolveParameterNames: %s", err)
+	}
+	err = ssmClient.DeleteParameters(names)
+	if err != nil {
+		t.Errorf("Error in DeleteParameters: %s", err)
+	}
+	if memory.deleteCalls != 2 {
+		t.Errorf("Expected 2 batches but got %d", memory.deleteCalls)
+	}
+	if len(memory.values) != 1 || memory.values["/keep/name"] == "" {
+		t.Errorf("Expected only /keep/name to be left but got %s", memory.values)
+	}
+}
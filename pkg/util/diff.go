@@ -0,0 +1,150 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	DiffAdded   = "added"
+	DiffRemoved = "removed"
+	DiffChanged = "changed"
+	DiffEqual   = "equal"
+
+	DiffFormatText  = "text"
+	DiffFormatJson  = "json"
+	DiffFormatTable = "table"
+
+	maskedValue = "****"
+)
+
+var ErrUnknownDiffFormat = errors.New("Unknown diff format")
+
+type DiffEntry struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	LeftValue  string `json:"left,omitempty"`
+	RightValue string `json:"right,omitempty"`
+}
+
+// DiffParams matches the keys of both sides by name and returns one entry per name, sorted by name.
+// Keys only on the right side are added, keys only on the left side are removed.
+func DiffParams(left map[string]string, right map[string]string) []DiffEntry {
+	names := GetSortedNamesFromParams(left)
+	for name := range right {
+		if _, ok := left[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	entries := make([]DiffEntry, 0, len(names))
+	for _, name := range names {
+		leftValue, inLeft := left[name]
+		rightValue, inRight := right[name]
+		entry := DiffEntry{
+			Name:       name,
+			LeftValue:  leftValue,
+			RightValue: rightValue,
+		}
+		switch {
+		case !inLeft:
+			entry.Status = DiffAdded
+		case !inRight:
+			entry.Status = DiffRemoved
+		case leftValue != rightValue:
+			entry.Status = DiffChanged
+		default:
+			entry.Status = DiffEqual
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// HasDrift returns true if any entry is not equal on both sides
+func HasDrift(entries []DiffEntry) bool {
+	for _, entry := range entries {
+		if entry.Status != DiffEqual {
+			return true
+		}
+	}
+	return false
+}
+
+// GetDiffOutputString renders all non equal entries as unified text, json or a table.
+// Values are replaced by a mask if showValues is false.
+func GetDiffOutputString(entries []DiffEntry, leftLabel string, rightLabel string, format string, showValues bool) (string, error) {
+	drift := make([]DiffEntry, 0)
+	for _, entry := range entries {
+		if entry.Status == DiffEqual {
+			continue
+		}
+		if !showValues {
+			entry.LeftValue = MaskValue(entry.LeftValue)
+			entry.RightValue = MaskValue(entry.RightValue)
+		}
+		drift = append(drift, entry)
+	}
+
+	switch format {
+	case DiffFormatText, "":
+		return diffAsText(drift, leftLabel, rightLabel), nil
+	case DiffFormatJson:
+		json, err := json.MarshalIndent(drift, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(json) + "\n", nil
+	case DiffFormatTable:
+		return diffAsTable(drift), nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownDiffFormat, format)
+	}
+}
+
+func diffAsText(entries []DiffEntry, leftLabel string, rightLabel string) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", leftLabel, rightLabel))
+	for _, entry := range entries {
+		switch entry.Status {
+		case DiffAdded:
+			builder.WriteString(fmt.Sprintf("+%s=%s\n", entry.Name, singleLineValue(entry.RightValue)))
+		case DiffRemoved:
+			builder.WriteString(fmt.Sprintf("-%s=%s\n", entry.Name, singleLineValue(entry.LeftValue)))
+		case DiffChanged:
+			builder.WriteString(fmt.Sprintf("-%s=%s\n", entry.Name, singleLineValue(entry.LeftValue)))
+			builder.WriteString(fmt.Sprintf("+%s=%s\n", entry.Name, singleLineValue(entry.RightValue)))
+		}
+	}
+	return builder.String()
+}
+
+func diffAsTable(entries []DiffEntry) string {
+	var builder strings.Builder
+	writer := tabwriter.NewWriter(&builder, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "STATUS\tNAME\tLEFT\tRIGHT")
+	for _, entry := range entries {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", entry.Status, entry.Name, singleLineValue(entry.LeftValue), singleLineValue(entry.RightValue))
+	}
+	writer.Flush()
+	return builder.String()
+}
+
+// singleLineValue keeps multiline values on a single line of the text diff or table row.
+// Backslashes are escaped as well, so a value containing \n stays distinguishable from a line break.
+func singleLineValue(value string) string {
+	return strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\r", "\\r").Replace(value)
+}
+
+// MaskValue hides a value in output, an empty value stays empty so it is still visible that nothing is set
+func MaskValue(value string) string {
+	if value == "" {
+		return ""
+	}
+	return maskedValue
+}
@@ -0,0 +1,168 @@
+package util
+
+import (
+	"testing"
+)
+
+func Test_DiffParams(t *testing.T) {
+	left := map[string]string{
+		"Both":    "Same",
+		"Changed": "Old",
+		"OnlyDev": "Dev",
+	}
+	right := map[string]string{
+		"Both":     "Same",
+		"Changed":  "New",
+		"OnlyProd": "Prod",
+	}
+	tests := []struct {
+		name   string
+		status string
+	}{
+		{
+			name:   "Both",
+			status: DiffEqual,
+		},
+		{
+			name:   "Changed",
+			status: DiffChanged,
+		},
+		{
+			name:   "OnlyDev",
+			status: DiffRemoved,
+		},
+		{
+			name:   "OnlyProd",
+			status: DiffAdded,
+		},
+	}
+	entries := DiffParams(left, right)
+	if len(entries) != len(tests) {
+		t.Fatalf("Expected %d entries but got %d", len(tests), len(entries))
+	}
+	if !HasDrift(entries) {
+		t.Error("Expected drift")
+	}
+	for index, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if entries[index].Name != tt.name {
+				t.Errorf("Expected %s but got %s", tt.name, entries[index].Name)
+			}
+			if entries[index].Status != tt.status {
+				t.Errorf("Expected %s but got %s", tt.status, entries[index].Status)
+			}
+		})
+	}
+}
+
+func Test_HasDrift(t *testing.T) {
+	entries := DiffParams(map[string]string{"One1": "OneVal1"}, map[string]string{"One1": "OneVal1"})
+	if HasDrift(entries) {
+		t.Error("Expected no drift")
+	}
+}
+
+func Test_GetDiffOutputString(t *testing.T) {
+	left := map[string]string{
+		"Both":    "Same",
+		"Changed": "Old",
+		"OnlyDev": "Dev",
+	}
+	right := map[string]string{
+		"Both":     "Same",
+		"Changed":  "New",
+		"OnlyProd": "Prod",
+	}
+	tests := []struct {
+		name       string
+		format     string
+		showValues bool
+		want       string
+	}{
+		{
+			name:       "text",
+			format:     DiffFormatText,
+			showValues: true,
+			want:       "--- /dev\n+++ /prod\n-Changed=Old\n+Changed=New\n-OnlyDev=Dev\n+OnlyProd=Prod\n",
+		},
+		{
+			name:       "text masked",
+			format:     DiffFormatText,
+			showValues: false,
+			want:       "--- /dev\n+++ /prod\n-Changed=****\n+Changed=****\n-OnlyDev=****\n+OnlyProd=****\n",
+		},
+		{
+			name:       "json",
+			format:     DiffFormatJson,
+			showValues: true,
+			want: "[\n" +
+				"  {\n    \"name\": \"Changed\",\n    \"status\": \"changed\",\n    \"left\": \"Old\",\n    \"right\": \"New\"\n  },\n" +
+				"  {\n    \"name\": \"OnlyDev\",\n    \"status\": \"removed\",\n    \"left\": \"Dev\"\n  },\n" +
+				"  {\n    \"name\": \"OnlyProd\",\n    \"status\": \"added\",\n    \"right\": \"Prod\"\n  }\n" +
+				"]\n",
+		},
+		{
+			name:       "table",
+			format:     DiffFormatTable,
+			showValues: true,
+			want: "STATUS   NAME      LEFT  RIGHT\n" +
+				"changed  Changed   Old   New\n" +
+				"removed  OnlyDev   Dev   \n" +
+				"added    OnlyProd        Prod\n",
+		},
+	}
+	entries := DiffParams(left, right)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := GetDiffOutputString(entries, "/dev", "/prod", tt.format, tt.showValues)
+			if err != nil {
+				t.Error("Error in GetDiffOutputString")
+			}
+			if output != tt.want {
+				t.Errorf("Expected '%s' but got '%s'", tt.want, output)
+			}
+		})
+	}
+
+	_, err := GetDiffOutputString(entries, "/dev", "/prod", "xml", true)
+	if err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
+
+func Test_GetDiffOutputStringMultiline(t *testing.T) {
+	left := map[string]string{"Cert": "line1\nline2", "Share": "\\\\server\\share"}
+	right := map[string]string{"Cert": "line1\r\nline3", "Share": "\\\\server\\other"}
+	tests := []struct {
+		name   string
+		format string
+		want   string
+	}{
+		{
+			name:   "text",
+			format: DiffFormatText,
+			want: "--- /dev\n+++ /prod\n" +
+				"-Cert=line1\\nline2\n+Cert=line1\\r\\nline3\n" +
+				"-Share=\\\\\\\\server\\\\share\n+Share=\\\\\\\\server\\\\other\n",
+		},
+		{
+			name:   "table",
+			format: DiffFormatTable,
+			want: "STATUS   NAME   LEFT               RIGHT\n" +
+				"changed  Cert   line1\\nline2       line1\\r\\nline3\n" +
+				"changed  Share  \\\\\\\\server\\\\share  \\\\\\\\server\\\\other\n",
+		},
+	}
+	entries := DiffParams(left, right)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := GetDiffOutputString(entries, "/dev", "/prod", tt.format, true)
+			if err != nil {
+				t.Error("Error in GetDiffOutputString")
+			}
+			if output != tt.want {
+				t.Errorf("Expected '%s' but got '%s'", tt.want, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+var ErrDotenvSyntax = errors.New("Invalid dotenv syntax")
+
+// bareValuePattern matches values which are read back unchanged without quotes by shells
+var bareValuePattern = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]*$`)
+
+// doubleQuoteEscaper escapes the characters which are special inside double quotes
+var doubleQuoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "`", "\\`")
+
+// FormatDotenvValue quotes and escapes a value, so it is read back byte for byte.
+// For export lines which are evaluated by a shell, values with special characters are wrapped in single quotes,
+// a single quote in the value closes the quoting, is escaped with a backslash and the quoting is opened again.
+// Otherwise values stay bare, as docker --env-file keeps quotes. Only values which ParseDotenv can not read back
+// bare, and all values with quote set, are wrapped in double quotes, escaping \ " $ and backticks.
+func FormatDotenvValue(value string, export bool, quote bool) string {
+	if export {
+		if !quote && bareValuePattern.MatchString(value) {
+			return value
+		}
+		return singleQuote(value)
+	}
+	if quote || needsDoubleQuotes(value) {
+		return `"` + doubleQuoteEscaper.Replace(value) + `"`
+	}
+	return value
+}
+
+// needsDoubleQuotes checks for values which change when they are read bare: line breaks, surrounding whitespace
+// which is trimmed, a comment after whitespace, a leading backslash which escapes, and leading or trailing quotes
+func needsDoubleQuotes(value string) bool {
+	if strings.ContainsAny(value, "\n\r") || strings.Contains(value, " #") || strings.Contains(value, "\t#") {
+		return true
+	}
+	if value != strings.Trim(value, " \t") || strings.HasPrefix(value, `\`) {
+		return true
+	}
+	for _, quote := range []string{"'", `"`} {
+		if strings.HasPrefix(value, quote) || strings.HasSuffix(value, quote) {
+			return true
+		}
+	}
+	return false
+}
+
+// singleQuote wraps a value in single quotes for a shell, a single quote ends the quoting and is escaped
+func singleQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// ParseDotenv reads KEY=value entries as written by get, with or without --export and --quote.
+// Supports comments, export prefixes, single and double quoted values which can span multiple lines,
+// concatenated quoted and escaped segments as in export lines, and comments after values.
+// Lines can end with CRLF, line breaks inside quotes are kept as they are. Lines without a key are ignored.
+func ParseDotenv(content string) (map[string]string, error) {
+	parser := dotenvParser{input: []rune(content), line: 1}
+	params := make(map[string]string)
+	for {
+		parser.skipBlank()
+		if parser.done() {
+			return params, nil
+		}
+		line := parser.line
+		key, value, err := parser.entry()
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %s", ErrDotenvSyntax, line, err.Error())
+		}
+		if key == "" {
+			log.Warn().Msgf("Ignoring line %d without a key", line)
+			continue
+		}
+		log.Debug().Msgf("NAME: %s VALUE: %s", key, value)
+		params[key] = value
+	}
+}
+
+type dotenvParser struct {
+	input []rune
+	pos   int
+	line  int
+}
+
+func (p *dotenvParser) done() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *dotenvParser) peek() rune {
+	if p.done() {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *dotenvParser) next() rune {
+	char := p.input[p.pos]
+	p.pos++
+	if char == '\n' {
+		p.line++
+	}
+	return char
+}
+
+// lineEnd returns true at a LF or CRLF line ending
+func (p *dotenvParser) lineEnd() bool {
+	return p.peek() == '\n' || (p.peek() == '\r' && p.pos+1 < len(p.input) && p.input[p.pos+1] == '\n')
+}
+
+func isDotenvSpace(char rune) bool {
+	return char == ' ' || char == '\t'
+}
+
+// skipBlank skips whitespace, empty lines and comment lines
+func (p *dotenvParser) skipBlank() {
+	for !p.done() {
+		switch char := p.peek(); {
+		case isDotenvSpace(char) || p.lineEnd():
+			p.next()
+		case char == '#':
+			p.skipLine()
+		default:
+			return
+		}
+	}
+}
+
+func (p *dotenvParser) skipLine() {
+	for !p.done() && p.next() != '\n' {
+	}
+}
+
+// entry reads one KEY=value entry up to the end of its line
+func (p *dotenvParser) entry() (string, string, error) {
+	start := p.pos
+	for !p.done() && p.peek() != '=' && !p.lineEnd() {
+		p.next()
+	}
+	if p.peek() != '=' {
+		return "", "", fmt.Errorf("missing '=' in %q", strings.TrimSpace(string(p.input[start:p.pos])))
+	}
+	key := strings.TrimSpace(string(p.input[start:p.pos]))
+	p.next()
+	if strings.HasPrefix(key, "export") && len(key) > len("export") && isDotenvSpace(rune(key[len("export")])) {
+		key = strings.TrimSpace(key[len("export"):])
+	}
+	if strings.ContainsAny(key, " \t'\"#") {
+		return "", "", fmt.Errorf("invalid key %q", key)
+	}
+
+	for isDotenvSpace(p.peek()) {
+		p.next()
+	}
+	value, err := p.value()
+	if err != nil {
+		return "", "", err
+	}
+	return key, value, nil
+}
+
+// value reads quoted and bare segments until a comment or the end of the line.
+// After bare text quotes and backslashes are literal, as docker --env-file reads them, but unlike docker
+// whitespace followed by # starts a comment and whitespace at the end of the line is not part of the value.
+func (p *dotenvParser) value() (string, error) {
+	var value strings.Builder
+	bare := false
+	for !p.done() {
+		char := p.peek()
+		switch {
+		case p.lineEnd():
+			p.skipLine()
+			return value.String(), nil
+		case bare && (char == '\'' || char == '"' || char == '\\'):
+			value.WriteRune(p.next())
+		case char == '\'':
+			if err := p.singleQuoted(&value); err != nil {
+				return "", err
+			}
+		case char == '"':
+			if err := p.doubleQuoted(&value); err != nil {
+				return "", err
+			}
+		case isDotenvSpace(char):
+			spaceStart := p.pos
+			for isDotenvSpace(p.peek()) {
+				p.next()
+			}
+			// whitespace before a comment or the end of the line is not part of the value
+			if p.done() || p.lineEnd() || p.peek() == '#' {
+				p.skipLine()
+				return value.String(), nil
+			}
+			value.WriteString(string(p.input[spaceStart:p.pos]))
+		case char == '\\':
+			p.next()
+			// outside of quotes a backslash only escapes characters which would end or start a segment
+			if strings.ContainsRune("'\"\\$ #", p.peek()) {
+				value.WriteRune(p.next())
+			} else {
+				value.WriteRune(char)
+			}
+		default:
+			value.WriteRune(p.next())
+			bare = true
+		}
+	}
+	return value.String(), nil
+}
+
+// singleQuoted reads a value in single quotes literally
+func (p *dotenvParser) singleQuoted(value *strings.Builder) error {
+	p.next()
+	for !p.done() {
+		char := p.next()
+		if char == '\'' {
+			return nil
+		}
+		value.WriteRune(char)
+	}
+	return errors.New("single quote is not closed")
+}
+
+// doubleQuoted reads a value in double quotes, \n, \r and \t are replaced by their characters
+// and \ " $ and backticks are unescaped
+func (p *dotenvParser) doubleQuoted(value *strings.Builder) error {
+	p.next()
+	for !p.done() {
+		char := p.next()
+		switch char {
+		case '"':
+			return nil
+		case '\\':
+			if p.done() {
+				break
+			}
+			escaped := p.next()
+			switch escaped {
+			case 'n':
+				value.WriteRune('\n')
+			case 'r':
+				value.WriteRune('\r')
+			case 't':
+				value.WriteRune('\t')
+			case '\\', '"', '$', '`':
+				value.WriteRune(escaped)
+			case '\n':
+				// an escaped line break continues the value on the next line
+			default:
+				value.WriteRune(char)
+				value.WriteRune(escaped)
+			}
+		default:
+			value.WriteRune(char)
+		}
+	}
+	return errors.New("double quote is not closed")
+}
@@ -0,0 +1,201 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_FormatDotenvValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		export bool
+		quote  bool
+		want   string
+	}{
+		{name: "bare", value: "value-1/a.b:c,d=e", want: "value-1/a.b:c,d=e"},
+		{name: "empty", value: "", want: ""},
+		{name: "space", value: "hello world", want: "hello world"},
+		{name: "dollar", value: "pa$$word", want: "pa$$word"},
+		{name: "json", value: `{"key": "value"}`, want: `{"key": "value"}`},
+		{name: "single quote", value: "it's", want: "it's"},
+		{name: "backslash", value: `a\b`, want: `a\b`},
+		{name: "leading quote", value: `'quoted`, want: `"'quoted"`},
+		{name: "trailing quote", value: `say "hi"`, want: `"say \"hi\""`},
+		{name: "comment", value: "abc #def", want: `"abc #def"`},
+		{name: "comment after tab", value: "abc\t#def", want: "\"abc\t#def\""},
+		{name: "hash", value: "abc#def", want: "abc#def"},
+		{name: "trailing space", value: "abc ", want: `"abc "`},
+		{name: "trailing tab", value: "tab\t", want: "\"tab\t\""},
+		{name: "leading space", value: " abc", want: `" abc"`},
+		{name: "leading backslash", value: `\$HOME`, want: `"\\\$HOME"`},
+		{name: "unc path", value: `\\server\share`, want: `"\\\\server\\share"`},
+		{name: "carriage return", value: "a\rb", want: "\"a\rb\""},
+		{name: "export space", value: "hello world", export: true, want: "'hello world'"},
+		{name: "multiline", value: "line1\n$line2 \"`x`\"", want: "\"line1\n\\$line2 \\\"\\`x\\`\\\"\""},
+		{name: "quote", value: "value", quote: true, want: `"value"`},
+		{name: "quote escaped", value: `say "hi"`, quote: true, want: `"say \"hi\""`},
+		{name: "export bare", value: "value", export: true, want: "value"},
+		{name: "export", value: "it's $HOME\n", export: true, want: "'it'\\''s $HOME\n'"},
+		{name: "export quote", value: "value", export: true, quote: true, want: "'value'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FormatDotenvValue(tt.value, tt.export, tt.quote)
+			if got != tt.want {
+				t.Errorf("Expected '%s' but got '%s'", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_ParseDotenv(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:    "bare values",
+			content: "ONE=1\nTWO = two words \n\nTHREE=a=b\r\nEMPTY=\n",
+			want:    map[string]string{"ONE": "1", "TWO": "two words", "THREE": "a=b", "EMPTY": ""},
+		},
+		{
+			name:    "comments",
+			content: "# KEY=commented\n  # indented=comment\nKEY=value # comment\nHASH=a#b\nQUOTED='a # b' # comment\n",
+			want:    map[string]string{"KEY": "value", "HASH": "a#b", "QUOTED": "a # b"},
+		},
+		{
+			name:    "export prefix",
+			content: "export KEY=value\nexport  OTHER='it'\\''s'\nexported=1\n",
+			want:    map[string]string{"KEY": "value", "OTHER": "it's", "exported": "1"},
+		},
+		{
+			name:    "single quotes",
+			content: "KEY='$HOME \\n \"x\"'\n",
+			want:    map[string]string{"KEY": "$HOME \\n \"x\""},
+		},
+		{
+			name:    "double quotes",
+			content: "KEY=\"a\\\\b \\\"c\\\" \\$d \\`e\\` \\n\\t \\x\"\n",
+			want:    map[string]string{"KEY": "a\\b \"c\" $d `e` \n\t \\x"},
+		},
+		{
+			name:    "multiline",
+			content: "CERT=\"-----BEGIN-----\nabc\n-----END-----\n\"\nNEXT='a\nb'\nLAST=1",
+			want:    map[string]string{"CERT": "-----BEGIN-----\nabc\n-----END-----\n", "NEXT": "a\nb", "LAST": "1"},
+		},
+		{
+			name:    "crlf",
+			content: "ONE=1 # comment\r\n\r\nTWO='a\r\nb'\r\nTHREE=\"c\r\n\"\r\nFOUR=4 \r\n",
+			want:    map[string]string{"ONE": "1", "TWO": "a\r\nb", "THREE": "c\r\n", "FOUR": "4"},
+		},
+		{
+			name:    "empty key is ignored",
+			content: "One1=Value1\n=Error\n",
+			want:    map[string]string{"One1": "Value1"},
+		},
+		{
+			name:    "missing equal sign",
+			content: "ONE=1\nTWO\n",
+			wantErr: "Invalid dotenv syntax: line 2: missing '=' in \"TWO\"",
+		},
+		{
+			name:    "unclosed quote",
+			content: "ONE=1\n\nTWO=\"open\nTHREE=3\n",
+			wantErr: "Invalid dotenv syntax: line 3: double quote is not closed",
+		},
+		{
+			name:    "invalid key",
+			content: "MY KEY=1\n",
+			wantErr: "Invalid dotenv syntax: line 1: invalid key \"MY KEY\"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDotenv(tt.content)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("Expected error '%s' but got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %q but got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_ParseDotenvReadsOutput(t *testing.T) {
+	params := map[string]string{
+		"BARE":      "value",
+		"EMPTY":     "",
+		"WORDS":     "hello world",
+		"DOLLAR":    "pa$$word `id` $(id)",
+		"QUOTES":    `it's "quoted"`,
+		"LEADING":   `"quoted" value`,
+		"BACKSLASH": `C:\path\n`,
+		"PEM":       "-----BEGIN-----\nabc\\n\n-----END-----\n",
+		"JSON":      `{"key": "va'lue", "list": [1, 2]}`,
+		"SPACES":    "  two  words  ",
+		"COMMENT":   "value # not a comment",
+	}
+	for _, flags := range []Flags{{}, {Quote: true}, {Export: true}, {Export: true, Quote: true}} {
+		prefix := ""
+		if flags.Export {
+			prefix = "export "
+		}
+		got, err := ParseDotenv(OutputParamsAsString(params, prefix, flags))
+		if err != nil {
+			t.Fatalf("Expected no error for %+v but got %v", flags, err)
+		}
+		if !reflect.DeepEqual(got, params) {
+			t.Errorf("Expected %q for %+v but got %q", params, flags, got)
+		}
+	}
+}
+
+func Test_DotenvRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{name: "comment", value: "abc #def"},
+		{name: "comment after tab", value: "abc\t#def"},
+		{name: "trailing space", value: "abc "},
+		{name: "trailing tab", value: "tab\t"},
+		{name: "leading space", value: " abc"},
+		{name: "escaped dollar", value: `\$HOME`},
+		{name: "unc path", value: `\\server\share`},
+		{name: "crlf", value: "line1\r\nline2\r\n"},
+		{name: "carriage return", value: "a\rb"},
+	}
+	modes := []struct {
+		name   string
+		prefix string
+		flags  Flags
+	}{
+		{name: "default"},
+		{name: "quote", flags: Flags{Quote: true}},
+		{name: "export", prefix: "export ", flags: Flags{Export: true}},
+	}
+	for _, tt := range tests {
+		for _, mode := range modes {
+			t.Run(tt.name+"/"+mode.name, func(t *testing.T) {
+				want := map[string]string{"KEY": tt.value, "NEXT": "next"}
+				got, err := ParseDotenv(OutputParamsAsString(want, mode.prefix, mode.flags))
+				if err != nil {
+					t.Fatalf("Expected no error but got %v", err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("Expected %q but got %q", want, got)
+				}
+			})
+		}
+	}
+}
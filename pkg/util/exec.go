@@ -0,0 +1,98 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+var ErrInvalidEnvironment = errors.New("Invalid environment variable")
+
+// MergeEnvironment sets the params as variables in the environment, given as KEY=value entries.
+// With clean the environment is not inherited and only the params are set,
+// with noOverride variables which are already set keep their value.
+func MergeEnvironment(environ []string, params map[string]string, clean bool, noOverride bool) ([]string, error) {
+	for name, value := range params {
+		if name == "" || strings.ContainsAny(name, "=\x00") {
+			return nil, fmt.Errorf("%w: name %q", ErrInvalidEnvironment, name)
+		}
+		if strings.Contains(value, "\x00") {
+			return nil, fmt.Errorf("%w: value of %s contains a null character", ErrInvalidEnvironment, name)
+		}
+	}
+
+	merged := make([]string, 0, len(environ)+len(params))
+	set := make(map[string]bool)
+	if !clean {
+		for _, entry := range environ {
+			name := strings.SplitN(entry, "=", 2)[0]
+			value, ok := params[name]
+			if ok && !noOverride {
+				entry = name + "=" + value
+			} else if ok {
+				log.Debug().Msgf("Not overriding variable which is already set: %s", name)
+			}
+			set[name] = true
+			merged = append(merged, entry)
+		}
+	}
+	for _, name := range GetSortedNamesFromParams(params) {
+		if !set[name] {
+			merged = append(merged, name+"="+params[name])
+		}
+	}
+	return merged, nil
+}
+
+// RunCommand runs a command with the environment and returns its exit code.
+// Stdin, stdout and stderr are passed to the command, signals are forwarded to it.
+// Signals of the terminal are not forwarded if the command gets them directly, else it would get Ctrl-C twice.
+func RunCommand(name string, args []string, environ []string) (int, error) {
+	command := exec.Command(name, args...)
+	command.Env = environ
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwardedSignals...)
+	defer signal.Stop(signals)
+
+	if err := command.Start(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return 127, err
+		}
+		return 1, err
+	}
+
+	foreground := inForegroundGroup()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				if foreground && terminalSignal(sig) {
+					log.Debug().Msgf("Not forwarding signal, the command got it from the terminal: %s", sig)
+					continue
+				}
+				log.Debug().Msgf("Forwarding signal: %s", sig)
+				_ = command.Process.Signal(sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err := command.Wait()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return 1, err
+	}
+	return exitCode(command.ProcessState), nil
+}
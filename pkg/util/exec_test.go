@@ -0,0 +1,114 @@
+package util
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func Test_MergeEnvironment(t *testing.T) {
+	environ := []string{"PATH=/bin", "DB_HOST=prod", "EMPTY="}
+	params := map[string]string{"DB_HOST": "localhost", "DB_PORT": "5432", "EMPTY": "set"}
+	tests := []struct {
+		name       string
+		params     map[string]string
+		clean      bool
+		noOverride bool
+		want       []string
+		wantErr    bool
+	}{
+		{
+			name:   "override",
+			params: params,
+			want:   []string{"PATH=/bin", "DB_HOST=localhost", "EMPTY=set", "DB_PORT=5432"},
+		},
+		{
+			name:       "no override",
+			params:     params,
+			noOverride: true,
+			want:       []string{"PATH=/bin", "DB_HOST=prod", "EMPTY=", "DB_PORT=5432"},
+		},
+		{
+			name:   "clean",
+			params: params,
+			clean:  true,
+			want:   []string{"DB_HOST=localhost", "DB_PORT=5432", "EMPTY=set"},
+		},
+		{
+			name:    "invalid name",
+			params:  map[string]string{"A=B": "value"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid value",
+			params:  map[string]string{"A": "null\x00"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergeEnvironment(environ, tt.params, tt.clean, tt.noOverride)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %t but got %v", tt.wantErr, err)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v but got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_RunCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a unix shell")
+	}
+	tests := []struct {
+		name     string
+		script   string
+		want     int
+		notFound bool
+	}{
+		{name: "success", script: `test "$DB_HOST" = "localhost"`, want: 0},
+		{name: "exit code", script: "exit 3", want: 3},
+		{name: "signal", script: "kill -TERM $$", want: 143},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RunCommand("sh", []string{"-c", tt.script}, []string{"DB_HOST=localhost"})
+			if err != nil {
+				t.Errorf("Expected no error but got %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected exit code %d but got %d", tt.want, got)
+			}
+		})
+	}
+
+	got, err := RunCommand("aws-parameter-bulk-missing-command", nil, nil)
+	if !errors.Is(err, exec.ErrNotFound) || got != 127 {
+		t.Errorf("Expected exit code 127 and ErrNotFound but got %d %v", got, err)
+	}
+}
+
+func Test_inForegroundGroup(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs unix process groups")
+	}
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Error creating pipe: %s", err)
+	}
+	defer reader.Close()
+	defer writer.Close()
+	stdin := os.Stdin
+	os.Stdin = reader
+	defer func() { os.Stdin = stdin }()
+
+	// without a terminal on stdin the command gets no signals directly, so all of them are forwarded
+	if inForegroundGroup() {
+		t.Error("Expected no foreground group without a terminal")
+	}
+}
@@ -0,0 +1,104 @@
+package util
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// ParameterVersion is one version of a parameter from its history
+type ParameterVersion struct {
+	Name             string
+	Version          int64
+	Value            string
+	Type             string
+	LastModifiedDate time.Time
+	LastModifiedUser string
+	Labels           []string
+}
+
+// GetParameterHistory returns all versions of a parameter, the oldest first
+func (f *AWSSSM) GetParameterHistory(name string) ([]ParameterVersion, error) {
+	if err := rejectSecrets([]string{name}); err != nil {
+		return nil, err
+	}
+	if err := f.requireSSM(); err != nil {
+		return nil, err
+	}
+	versions := make([]ParameterVersion, 0)
+	var nextToken *string
+	for {
+		input := &ssm.GetParameterHistoryInput{
+			Name:           aws.String(name),
+			WithDecryption: &trueBool,
+			NextToken:      nextToken,
+		}
+		var output *ssm.GetParameterHistoryOutput
+		err := f.read("GetParameterHistory", func() (err error) {
+			output, err = f.SSM.GetParameterHistory(input)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, history := range output.Parameters {
+			versions = append(versions, ParameterVersion{
+				Name:             aws.StringValue(history.Name),
+				Version:          aws.Int64Value(history.Version),
+				Value:            aws.StringValue(history.Value),
+				Type:             aws.StringValue(history.Type),
+				LastModifiedDate: aws.TimeValue(history.LastModifiedDate),
+				LastModifiedUser: aws.StringValue(history.LastModifiedUser),
+				Labels:           aws.StringValueSlice(history.Labels),
+			})
+		}
+		nextToken = output.NextToken
+		if nextToken == nil {
+			break
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Version < versions[j].Version
+	})
+	return versions, nil
+}
+
+// GetHistory returns the versions of all parameters of a list of names and paths, sorted by name and version
+func (f *AWSSSM) GetHistory(paramstring *string, flags Flags) ([]ParameterVersion, error) {
+	names, err := f.ResolveParameterNames(paramstring, flags)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]ParameterVersion, 0)
+	for _, name := range names {
+		history, err := f.GetParameterHistory(name)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, history...)
+	}
+	return versions, nil
+}
+
+// GetHistoryOutputString outputs the versions as table, values are masked unless showValues is set
+func GetHistoryOutputString(versions []ParameterVersion, showValues bool) string {
+	var builder strings.Builder
+	writer := tabwriter.NewWriter(&builder, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "NAME\tVERSION\tMODIFIED\tUSER\tLABELS\tVALUE")
+	for _, version := range versions {
+		value := version.Value
+		if !showValues {
+			value = MaskValue(value)
+		}
+		fmt.Fprintf(writer, "%s\t%d\t%s\t%s\t%s\t%s\n", version.Name, version.Version,
+			version.LastModifiedDate.Format(time.RFC3339), version.LastModifiedUser,
+			strings.Join(version.Labels, ","), singleLineValue(value))
+	}
+	writer.Flush()
+	return builder.String()
+}
@@ -0,0 +1,85 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func newHistoryTestSSM() (*AWSSSM, *memorySSM) {
+	memory := newMemorySSM()
+	memory.add("/app/db_password", "first", "SecureString", "", "", nil)
+	memory.add("/app/db_user", "admin", "String", "", "", nil)
+	memory.add("/other/name", "other", "String", "", "", nil)
+	ssmClient := NewSSM()
+	ssmClient.SSM = memory
+	for _, value := range []string{"second", "third"} {
+		if err := ssmClient.SaveParameter("/app/db_password", value, ""); err != nil {
+			panic(err)
+		}
+	}
+	memory.history["/app/db_password"][1].Labels = aws.StringSlice([]string{"prod", "stable"})
+	return ssmClient, memory
+}
+
+func Test_GetParameterHistory(t *testing.T) {
+	ssmClient, _ := newHistoryTestSSM()
+	versions, err := ssmClient.GetParameterHistory("/app/db_password")
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(versions) != 3 {
+		t.Fatalf("Expected 3 versions over all pages but got %d", len(versions))
+	}
+	for index, want := range []string{"first", "second", "third"} {
+		if versions[index].Version != int64(index+1) || versions[index].Value != want {
+			t.Errorf("Expected version %d with %s but got %+v", index+1, want, versions[index])
+		}
+	}
+
+	_, err = ssmClient.GetParameterHistory("/app/missing")
+	if err == nil {
+		t.Errorf("Expected an error for a missing parameter")
+	}
+}
+
+func Test_GetHistoryOutputString(t *testing.T) {
+	tests := []struct {
+		name        string
+		paramstring string
+		showValues  bool
+		want        string
+	}{
+		{
+			name:        "masked",
+			paramstring: "/app/db_password",
+			want: "NAME              VERSION  MODIFIED              USER                                 LABELS       VALUE\n" +
+				"/app/db_password  1        2024-01-01T01:00:00Z  arn:aws:iam::111111111111:user/test               ****\n" +
+				"/app/db_password  2        2024-01-01T04:00:00Z  arn:aws:iam::111111111111:user/test  prod,stable  ****\n" +
+				"/app/db_password  3        2024-01-01T05:00:00Z  arn:aws:iam::111111111111:user/test               ****\n",
+		},
+		{
+			name:        "path with values",
+			paramstring: "/app",
+			showValues:  true,
+			want: "NAME              VERSION  MODIFIED              USER                                 LABELS       VALUE\n" +
+				"/app/db_password  1        2024-01-01T01:00:00Z  arn:aws:iam::111111111111:user/test               first\n" +
+				"/app/db_password  2        2024-01-01T04:00:00Z  arn:aws:iam::111111111111:user/test  prod,stable  second\n" +
+				"/app/db_password  3        2024-01-01T05:00:00Z  arn:aws:iam::111111111111:user/test               third\n" +
+				"/app/db_user      1        2024-01-01T02:00:00Z  arn:aws:iam::111111111111:user/test               admin\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ssmClient, _ := newHistoryTestSSM()
+			versions, err := ssmClient.GetHistory(&tt.paramstring, Flags{Recursive: true})
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			got := GetHistoryOutputString(versions, tt.showValues)
+			if got != tt.want {
+				t.Errorf("Expected\n%s\nbut got\n%s", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package util
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	ErrManifestName    = errors.New("A name is needed for kubernetes output")
+	ErrManifestKey     = errors.New("Invalid key for kubernetes data, allowed are alphanumeric characters, '-', '_' and '.'")
+	ErrSplitNeedsTypes = errors.New("Split by type needs the types of the parameters")
+
+	manifestKeyPattern = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+)
+
+type manifestMetadata struct {
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+type manifest struct {
+	ApiVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   manifestMetadata  `yaml:"metadata"`
+	Type       string            `yaml:"type,omitempty"`
+	Data       map[string]string `yaml:"data"`
+}
+
+// isManifestOutput checks if the output format is a kubernetes manifest
+func isManifestOutput(format string) bool {
+	return format == OutputK8sSecret || format == OutputK8sConfigMap
+}
+
+// GetParamsOutputString works like GetOutputString, and uses the types of the parameters
+// to split the values into a Secret and a ConfigMap
+func (f *AWSSSM) GetParamsOutputString(results map[string]Parameter, flags Flags) (string, error) {
+	format, err := getOutputFormat(flags)
+	if err != nil {
+		log.Error().Msg(err.Error())
+		return "", err
+	}
+	if !isManifestOutput(format) {
+		return f.GetOutputString(GetValuesFromParams(results), flags)
+	}
+	result, err := getManifestOutput(results, format, flags)
+	if err != nil {
+		log.Error().Msg(err.Error())
+	}
+	return result, err
+}
+
+// getManifestOutput outputs the parameters as Secret or ConfigMap manifest. With SplitByType
+// SecureStrings are written to a Secret and all other values to a ConfigMap.
+func getManifestOutput(results map[string]Parameter, format string, flags Flags) (string, error) {
+	if flags.ManifestName == "" {
+		return "", ErrManifestName
+	}
+	if flags.Export || flags.Nested || flags.Quote {
+		return "", fmt.Errorf("export, nested and quote can not be used with %s output", format)
+	}
+	for name := range results {
+		if len(name) > 253 || !manifestKeyPattern.MatchString(name) {
+			return "", fmt.Errorf("%w: %s", ErrManifestKey, name)
+		}
+	}
+
+	secretValues := make(map[string]string)
+	configValues := make(map[string]string)
+	for name, param := range results {
+		if flags.SplitByType {
+			if param.Type == "" {
+				return "", ErrSplitNeedsTypes
+			}
+			if param.Type == ssm.ParameterTypeSecureString {
+				secretValues[name] = param.Value
+			} else {
+				configValues[name] = param.Value
+			}
+		} else if format == OutputK8sSecret {
+			secretValues[name] = param.Value
+		} else {
+			if param.Type == ssm.ParameterTypeSecureString {
+				log.Warn().Msgf("SecureString is written to a ConfigMap in plain text: %s", param.Name)
+			}
+			configValues[name] = param.Value
+		}
+	}
+
+	documents := make([]string, 0, 2)
+	if flags.SplitByType || format == OutputK8sSecret {
+		document, err := encodeYaml(newSecretManifest(secretValues, flags))
+		if err != nil {
+			return "", err
+		}
+		documents = append(documents, document)
+	}
+	if flags.SplitByType || format == OutputK8sConfigMap {
+		document, err := encodeYaml(newConfigMapManifest(configValues, flags))
+		if err != nil {
+			return "", err
+		}
+		documents = append(documents, document)
+	}
+	return strings.Join(documents, "---\n"), nil
+}
+
+func newSecretManifest(values map[string]string, flags Flags) manifest {
+	data := make(map[string]string, len(values))
+	for name, value := range values {
+		data[name] = base64.StdEncoding.EncodeToString([]byte(value))
+	}
+	return manifest{
+		ApiVersion: "v1",
+		Kind:       "Secret",
+		Metadata:   manifestMetadata{Name: flags.ManifestName, Namespace: flags.Namespace},
+		Type:       "Opaque",
+		Data:       data,
+	}
+}
+
+func newConfigMapManifest(values map[string]string, flags Flags) manifest {
+	return manifest{
+		ApiVersion: "v1",
+		Kind:       "ConfigMap",
+		Metadata:   manifestMetadata{Name: flags.ManifestName, Namespace: flags.Namespace},
+		Data:       values,
+	}
+}
@@ -0,0 +1,75 @@
+package util
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_GetParamsOutputStringManifest(t *testing.T) {
+	params := map[string]Parameter{
+		"DB_PASSWORD": {Name: "/app/DB_PASSWORD", Value: "secret", Type: "SecureString"},
+		"DB_HOST":     {Name: "/app/DB_HOST", Value: "localhost", Type: "String"},
+	}
+	tests := []struct {
+		name    string
+		params  map[string]Parameter
+		flags   Flags
+		want    string
+		wantErr error
+	}{
+		{
+			name:   "secret",
+			params: params,
+			flags:  Flags{Output: OutputK8sSecret, ManifestName: "app-secrets", Namespace: "dev"},
+			want: "apiVersion: v1\nkind: Secret\nmetadata:\n  name: app-secrets\n  namespace: dev\ntype: Opaque\n" +
+				"data:\n  DB_HOST: bG9jYWxob3N0\n  DB_PASSWORD: c2VjcmV0\n",
+		},
+		{
+			name:   "configmap without namespace",
+			params: params,
+			flags:  Flags{Output: OutputK8sConfigMap, ManifestName: "app-config"},
+			want: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app-config\n" +
+				"data:\n  DB_HOST: localhost\n  DB_PASSWORD: secret\n",
+		},
+		{
+			name:   "split by type",
+			params: params,
+			flags:  Flags{Output: OutputK8sSecret, ManifestName: "app", Namespace: "dev", SplitByType: true},
+			want: "apiVersion: v1\nkind: Secret\nmetadata:\n  name: app\n  namespace: dev\ntype: Opaque\n" +
+				"data:\n  DB_PASSWORD: c2VjcmV0\n" +
+				"---\n" +
+				"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n  namespace: dev\n" +
+				"data:\n  DB_HOST: localhost\n",
+		},
+		{
+			name:    "missing name",
+			params:  params,
+			flags:   Flags{Output: OutputK8sSecret},
+			wantErr: ErrManifestName,
+		},
+		{
+			name:    "invalid key",
+			params:  map[string]Parameter{"/app/DB_HOST": {Name: "/app/DB_HOST", Value: "localhost", Type: "String"}},
+			flags:   Flags{Output: OutputK8sConfigMap, ManifestName: "app"},
+			wantErr: ErrManifestKey,
+		},
+		{
+			name:    "split without types",
+			params:  map[string]Parameter{"DB_HOST": {Name: "DB_HOST", Value: "localhost"}},
+			flags:   Flags{Output: OutputK8sConfigMap, ManifestName: "app", SplitByType: true},
+			wantErr: ErrSplitNeedsTypes,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ssmClient := NewSSM()
+			got, err := ssmClient.GetParamsOutputString(tt.params, tt.flags)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected error %v but got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected '%s' but got '%s'", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/rs/zerolog/log"
+)
+
+var ErrInvalidLabel = errors.New("Invalid label, use up to 100 letters, numbers, '.', '-' or '_', not only numbers and not starting with aws or ssm")
+
+var labelPattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]{1,100}$`)
+
+// ValidateLabel checks the rules of SSM for labels, a label of only numbers could not be told apart from a version
+func ValidateLabel(label string) error {
+	lower := strings.ToLower(label)
+	if !labelPattern.MatchString(label) || isVersionSelector(label) ||
+		strings.HasPrefix(lower, "aws") || strings.HasPrefix(lower, "ssm") {
+		return fmt.Errorf("%w: %s", ErrInvalidLabel, label)
+	}
+	return nil
+}
+
+// LabelParameters attaches the label to the current version of each parameter, SSM moves it away from older versions
+func (f *AWSSSM) LabelParameters(names []string, label string) error {
+	if err := f.requireSSM(); err != nil {
+		return err
+	}
+	if err := ValidateLabel(label); err != nil {
+		return err
+	}
+	// results read with a label selector change
+	defer f.clearCache()
+	for _, name := range names {
+		input := &ssm.LabelParameterVersionInput{
+			Name:   aws.String(name),
+			Labels: aws.StringSlice([]string{label}),
+		}
+		var output *ssm.LabelParameterVersionOutput
+		err := f.write("LabelParameterVersion", func() (err error) {
+			output, err = f.SSM.LabelParameterVersion(input)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if len(output.InvalidLabels) > 0 {
+			return fmt.Errorf("%w: %s", ErrInvalidLabel, strings.Join(aws.StringValueSlice(output.InvalidLabels), ","))
+		}
+		log.Debug().Msgf("Labelled: %s:%d %s", name, aws.Int64Value(output.ParameterVersion), label)
+	}
+	return nil
+}
+
+// UnlabelParameters removes the label from the version of each parameter which has it, names without the label are ignored
+func (f *AWSSSM) UnlabelParameters(names []string, label string) error {
+	if err := f.requireSSM(); err != nil {
+		return err
+	}
+	defer f.clearCache()
+	for _, name := range names {
+		versions, err := f.GetParameterHistory(name)
+		if err != nil {
+			return err
+		}
+		version, ok := labelledVersion(versions, label)
+		if !ok {
+			log.Warn().Msgf("Not unlabelled, has no version with the label: %s", name)
+			continue
+		}
+		input := &ssm.UnlabelParameterVersionInput{
+			Name:             aws.String(name),
+			ParameterVersion: aws.Int64(version.Version),
+			Labels:           aws.StringSlice([]string{label}),
+		}
+		err = f.write("UnlabelParameterVersion", func() error {
+			_, err := f.SSM.UnlabelParameterVersion(input)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		log.Debug().Msgf("Unlabelled: %s:%d %s", name, version.Version, label)
+	}
+	return nil
+}
+
+// labelledVersion returns the version which has the label, a label is on at most one version
+func labelledVersion(versions []ParameterVersion, label string) (ParameterVersion, bool) {
+	for _, version := range versions {
+		for _, versionLabel := range version.Labels {
+			if versionLabel == label {
+				return version, true
+			}
+		}
+	}
+	return ParameterVersion{}, false
+}
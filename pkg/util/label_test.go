@@ -0,0 +1,82 @@
+package util
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func Test_ValidateLabel(t *testing.T) {
+	tests := []struct {
+		label   string
+		wantErr bool
+	}{
+		{label: "release-42"},
+		{label: "prod_v1.2"},
+		{label: "", wantErr: true},
+		{label: "42", wantErr: true},
+		{label: "awsrelease", wantErr: true},
+		{label: "SSM-release", wantErr: true},
+		{label: "release/42", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			err := ValidateLabel(tt.label)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateLabel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidLabel) {
+				t.Errorf("Expected ErrInvalidLabel but got %v", err)
+			}
+		})
+	}
+}
+
+func Test_LabelParameters(t *testing.T) {
+	ssmClient, memory := newHistoryTestSSM()
+
+	// prod is on version 2 and moves to the current version 3
+	if err := ssmClient.LabelParameters([]string{"/app/db_password", "/app/db_user"}, "prod"); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	password := memory.history["/app/db_password"]
+	if containsString(password[1].Labels, "prod") || !containsString(password[1].Labels, "stable") {
+		t.Errorf("Expected prod to be moved from version 2 but got %v", aws.StringValueSlice(password[1].Labels))
+	}
+	if !containsString(password[2].Labels, "prod") {
+		t.Errorf("Expected prod on version 3 but got %v", aws.StringValueSlice(password[2].Labels))
+	}
+	if !containsString(memory.history["/app/db_user"][0].Labels, "prod") {
+		t.Errorf("Expected prod on /app/db_user")
+	}
+
+	paramstring := "/app:prod"
+	params, err := ssmClient.GetParams(&paramstring, Flags{PrefixPath: true, Recursive: true})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if params["/app/db_password"] != "third" || params["/app/db_user"] != "admin" {
+		t.Errorf("Expected the labelled versions to be read but got %v", params)
+	}
+
+	if err := ssmClient.LabelParameters([]string{"/app/db_user"}, "42"); !errors.Is(err, ErrInvalidLabel) {
+		t.Errorf("Expected ErrInvalidLabel but got %v", err)
+	}
+}
+
+func Test_UnlabelParameters(t *testing.T) {
+	ssmClient, memory := newHistoryTestSSM()
+
+	if err := ssmClient.UnlabelParameters([]string{"/app/db_password", "/app/db_user"}, "prod"); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	for _, version := range memory.history["/app/db_password"] {
+		if containsString(version.Labels, "prod") {
+			t.Errorf("Expected prod to be removed but got it on version %d", *version.Version)
+		}
+	}
+	if !containsString(memory.history["/app/db_password"][1].Labels, "stable") {
+		t.Errorf("Expected stable to be kept")
+	}
+}
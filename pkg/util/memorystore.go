@@ -0,0 +1,112 @@
+package util
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// MemoryStore is a Store which keeps the parameters in memory, for tests and local use.
+// It has no versions or labels, names and paths with a selector find nothing.
+type MemoryStore struct {
+	mutex  sync.Mutex
+	values map[string]string
+	types  map[string]string
+}
+
+// NewMemoryStore creates a store with the values by full name, all of type String
+func NewMemoryStore(values map[string]string) *MemoryStore {
+	store := &MemoryStore{
+		values: make(map[string]string, len(values)),
+		types:  make(map[string]string, len(values)),
+	}
+	for name, value := range values {
+		store.values[name] = value
+		store.types[name] = ssm.ParameterTypeString
+	}
+	return store
+}
+
+func (m *MemoryStore) parameter(name string) Parameter {
+	return Parameter{Name: name, Value: m.values[name], Type: m.types[name]}
+}
+
+func (m *MemoryStore) GetByNames(names []string) ([]Parameter, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	params := make([]Parameter, 0, len(names))
+	for _, name := range names {
+		if _, ok := m.values[name]; ok {
+			params = append(params, m.parameter(name))
+		}
+	}
+	return params, nil
+}
+
+func (m *MemoryStore) GetTypes(names []string) (map[string]string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	types := make(map[string]string)
+	for _, name := range names {
+		if paramType, ok := m.types[name]; ok {
+			types[name] = paramType
+		}
+	}
+	return types, nil
+}
+
+func (m *MemoryStore) GetByPath(path string, recursive bool) ([]Parameter, error) {
+	names, err := m.List(path, recursive)
+	if err != nil {
+		return nil, err
+	}
+	return m.GetByNames(names)
+}
+
+func (m *MemoryStore) Put(param ParameterDetails, overwrite bool) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, ok := m.values[param.Name]; ok && !overwrite {
+		return false, nil
+	}
+	paramType := param.Type
+	if paramType == "" {
+		paramType = ssm.ParameterTypeString
+	}
+	m.values[param.Name] = param.Value
+	m.types[param.Name] = paramType
+	return true, nil
+}
+
+func (m *MemoryStore) Delete(names []string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, name := range names {
+		delete(m.values, name)
+		delete(m.types, name)
+	}
+	return nil
+}
+
+func (m *MemoryStore) List(path string, recursive bool) ([]string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	names := make([]string, 0)
+	if _, selector := SplitSelector(path); selector != "" {
+		return names, nil
+	}
+	prefix := strings.TrimSuffix(path, "/") + "/"
+	for name := range m.values {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if !recursive && strings.Contains(name[len(prefix):], "/") {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
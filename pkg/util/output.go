@@ -0,0 +1,113 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	OutputEnv  = "env"
+	OutputJson = "json"
+	OutputYaml = "yaml"
+	// OutputK8sSecret and OutputK8sConfigMap are kubernetes manifests with the values as data
+	OutputK8sSecret    = "k8s-secret"
+	OutputK8sConfigMap = "k8s-configmap"
+)
+
+var (
+	ErrUnknownOutput = errors.New("Unknown output format, use env, json, yaml, k8s-secret or k8s-configmap")
+	ErrNestedKey     = errors.New("Name is a value and a path, can not be nested")
+)
+
+// getOutputFormat returns the output format of the flags, env if none is given
+func getOutputFormat(flags Flags) (string, error) {
+	format := flags.Output
+	if flags.OutJson {
+		if format != "" && format != OutputJson {
+			return "", fmt.Errorf("outjson can not be used with %s output", format)
+		}
+		format = OutputJson
+	}
+	switch format {
+	case "":
+		return OutputEnv, nil
+	case OutputEnv, OutputJson, OutputYaml, OutputK8sSecret, OutputK8sConfigMap:
+		return format, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnknownOutput, format)
+}
+
+func getJsonOutput(results map[string]string, flags Flags) (string, error) {
+	var output []byte
+	var err error
+	if flags.Nested {
+		nested, nestErr := nestParams(results)
+		if nestErr != nil {
+			return "", nestErr
+		}
+		output, err = json.MarshalIndent(nested, "", "  ")
+	} else {
+		output, err = json.MarshalIndent(results, "", "  ")
+	}
+	return string(output), err
+}
+
+func getYamlOutput(results map[string]string, flags Flags) (string, error) {
+	var value interface{} = results
+	if flags.Nested {
+		nested, err := nestParams(results)
+		if err != nil {
+			return "", err
+		}
+		value = nested
+	}
+	return encodeYaml(value)
+}
+
+func encodeYaml(value interface{}) (string, error) {
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(value); err != nil {
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+// nestParams turns names with a path into nested maps, /dev/app/db/host becomes dev: app: db: host:
+func nestParams(results map[string]string) (map[string]interface{}, error) {
+	nested := make(map[string]interface{})
+	for _, name := range GetSortedNamesFromParams(results) {
+		parts := strings.FieldsFunc(name, func(r rune) bool { return r == '/' })
+		if len(parts) == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrNestedKey, name)
+		}
+		current := nested
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := current[part]
+			if !ok {
+				child = make(map[string]interface{})
+				current[part] = child
+			}
+			childMap, ok := child.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", ErrNestedKey, name)
+			}
+			current = childMap
+		}
+		last := parts[len(parts)-1]
+		if _, ok := current[last]; ok {
+			return nil, fmt.Errorf("%w: %s", ErrNestedKey, name)
+		}
+		current[last] = results[name]
+	}
+	return nested, nil
+}
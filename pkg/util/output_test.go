@@ -0,0 +1,117 @@
+package util
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_GetOutputStringFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  map[string]string
+		flags   Flags
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "yaml flat",
+			params: map[string]string{"B": "two", "A": "one"},
+			flags:  Flags{Output: OutputYaml},
+			want:   "A: one\nB: two\n",
+		},
+		{
+			name:   "yaml quotes values which are no strings",
+			params: map[string]string{"NUM": "0123", "BOOL": "true", "EMPTY": "", "COLON": "a: b"},
+			flags:  Flags{Output: OutputYaml},
+			want:   "BOOL: \"true\"\nCOLON: 'a: b'\nEMPTY: \"\"\nNUM: \"0123\"\n",
+		},
+		{
+			name:   "yaml multiline",
+			params: map[string]string{"CERT": "line1\nline2\n"},
+			flags:  Flags{Output: OutputYaml},
+			want:   "CERT: |\n  line1\n  line2\n",
+		},
+		{
+			name: "yaml nested",
+			params: map[string]string{
+				"/dev/app/db/host": "localhost",
+				"/dev/app/db/port": "5432",
+				"/dev/app/name":    "app",
+			},
+			flags: Flags{Output: OutputYaml, Nested: true},
+			want:  "dev:\n  app:\n    db:\n      host: localhost\n      port: \"5432\"\n    name: app\n",
+		},
+		{
+			name:   "json nested",
+			params: map[string]string{"/dev/app/name": "app"},
+			flags:  Flags{Output: OutputJson, Nested: true},
+			want:   "{\n  \"dev\": {\n    \"app\": {\n      \"name\": \"app\"\n    }\n  }\n}",
+		},
+		{
+			name:   "outjson and output json",
+			params: map[string]string{"A": "one"},
+			flags:  Flags{OutJson: true, Output: OutputJson},
+			want:   "{\n  \"A\": \"one\"\n}",
+		},
+		{
+			name:    "outjson and output yaml",
+			params:  map[string]string{"A": "one"},
+			flags:   Flags{OutJson: true, Output: OutputYaml},
+			wantErr: true,
+		},
+		{
+			name:    "export and yaml",
+			params:  map[string]string{"A": "one"},
+			flags:   Flags{Export: true, Output: OutputYaml},
+			wantErr: true,
+		},
+		{
+			name:    "nested env",
+			params:  map[string]string{"A": "one"},
+			flags:   Flags{Nested: true},
+			wantErr: true,
+		},
+		{
+			name:    "unknown output",
+			params:  map[string]string{"A": "one"},
+			flags:   Flags{Output: "toml"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ssmClient := NewSSM()
+			got, err := ssmClient.GetOutputString(tt.params, tt.flags)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %t but got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected '%s' but got '%s'", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_nestParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		params map[string]string
+	}{
+		{
+			name:   "value and path",
+			params: map[string]string{"/dev/app": "value", "/dev/app/db": "value"},
+		},
+		{
+			name:   "same name after splitting",
+			params: map[string]string{"/dev/app": "value", "/dev//app": "value"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := nestParams(tt.params)
+			if !errors.Is(err, ErrNestedKey) {
+				t.Errorf("Expected ErrNestedKey but got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/rs/zerolog/log"
+)
+
+var ErrTemplateParameter = errors.New("Parameter not found for template")
+
+// RenderOptions configures RenderTemplate
+type RenderOptions struct {
+	// Default is used for missing parameters if UseDefault is set, otherwise they are an error
+	Default    string
+	UseDefault bool
+	Recursive  bool
+}
+
+// templateLookup resolves the parameters of a template. In the first pass it only records the names and paths,
+// which are then read in batches, and the second pass uses the values.
+type templateLookup struct {
+	ssm        *AWSSSM
+	options    RenderOptions
+	recording  bool
+	names      map[string]bool
+	paths      map[string]bool
+	values     map[string]string
+	pathValues map[string]map[string]string
+}
+
+// RenderTemplate renders a go template, which reads parameters with the functions
+// ssm "name", ssmPath "/path" (a map of the names below the path) and ssmJson "name" "key"
+func (f *AWSSSM) RenderTemplate(name string, text string, options RenderOptions) (string, error) {
+	lookup := &templateLookup{
+		ssm:        f,
+		options:    options,
+		recording:  true,
+		names:      make(map[string]bool),
+		paths:      make(map[string]bool),
+		values:     make(map[string]string),
+		pathValues: make(map[string]map[string]string),
+	}
+	tmpl, err := template.New(name).Funcs(template.FuncMap{
+		"ssm":     lookup.ssmValue,
+		"ssmPath": lookup.ssmPath,
+		"ssmJson": lookup.ssmJson,
+	}).Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	// errors of the first pass are reported by the second pass, parameters which were not recorded are read then
+	if err := tmpl.Execute(io.Discard, nil); err != nil {
+		log.Debug().Msgf("Recording template parameters stopped: %s", err.Error())
+	}
+	lookup.recording = false
+	if err := lookup.fetch(); err != nil {
+		return "", err
+	}
+
+	var result strings.Builder
+	if err := tmpl.Execute(&result, nil); err != nil {
+		return "", err
+	}
+	return result.String(), nil
+}
+
+// fetch reads the recorded names in batches of 10 and each recorded path
+func (l *templateLookup) fetch() error {
+	names := make([]string, 0, len(l.names))
+	for name := range l.names {
+		if _, selector := SplitSelector(name); selector != "" {
+			if err := l.fetchSelected(name); err != nil {
+				return err
+			}
+			continue
+		}
+		names = append(names, name)
+	}
+	for _, chunk := range chunkParamNames(aws.StringSlice(names), 10) {
+		params, err := l.ssm.GetParameters(chunk, Flags{PrefixPath: true})
+		if err != nil && !errors.Is(err, ErrNameNotFound) {
+			return err
+		}
+		for _, param := range params {
+			l.values[param.Name] = param.Value
+		}
+	}
+	for path := range l.paths {
+		if err := l.fetchPath(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fetchSelected reads a single name and keeps the value under the requested name.
+// Parameters are returned without their :version or :label selector, so names with one are not read in batches.
+func (l *templateLookup) fetchSelected(name string) error {
+	params, err := l.ssm.GetParameters([]*string{aws.String(name)}, Flags{PrefixPath: true})
+	if err != nil && !errors.Is(err, ErrNameNotFound) {
+		return err
+	}
+	for _, param := range params {
+		l.values[name] = param.Value
+	}
+	return nil
+}
+
+func (l *templateLookup) fetchPath(path string) error {
+	params, err := l.ssm.GetParametersByPath([]string{path}, Flags{PrefixPath: true, Recursive: l.options.Recursive})
+	if err != nil && !errors.Is(err, ErrNameNotFound) {
+		return err
+	}
+	values := make(map[string]string, len(params))
+	prefix := strings.TrimSuffix(path, "/") + "/"
+	for _, param := range params {
+		key := strings.TrimPrefix(param.Name, prefix)
+		if key == param.Name {
+			// the path is a single parameter
+			key = key[strings.LastIndex(key, "/")+1:]
+		}
+		values[key] = param.Value
+	}
+	l.pathValues[path] = values
+	return nil
+}
+
+// value returns the value of a name, names which were not recorded in the first pass are read one by one
+func (l *templateLookup) value(name string) (string, bool, error) {
+	if l.recording {
+		l.names[name] = true
+		return "", true, nil
+	}
+	if !l.names[name] {
+		l.names[name] = true
+		if err := l.fetchSelected(name); err != nil {
+			return "", false, err
+		}
+	}
+	value, ok := l.values[name]
+	return value, ok, nil
+}
+
+func (l *templateLookup) missing(description string) (string, error) {
+	if l.options.UseDefault {
+		return l.options.Default, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrTemplateParameter, description)
+}
+
+func (l *templateLookup) ssmValue(name string) (string, error) {
+	value, ok, err := l.value(name)
+	if err != nil || ok {
+		return value, err
+	}
+	return l.missing(name)
+}
+
+func (l *templateLookup) ssmPath(path string) (map[string]string, error) {
+	if l.recording {
+		l.paths[path] = true
+		return map[string]string{}, nil
+	}
+	if _, ok := l.pathValues[path]; !ok {
+		if err := l.fetchPath(path); err != nil {
+			return nil, err
+		}
+	}
+	values := l.pathValues[path]
+	if len(values) == 0 && !l.options.UseDefault {
+		return nil, fmt.Errorf("%w: %s", ErrTemplateParameter, path)
+	}
+	return values, nil
+}
+
+func (l *templateLookup) ssmJson(name string, key string) (string, error) {
+	value, ok, err := l.value(name)
+	if err != nil || l.recording {
+		return "", err
+	}
+	if !ok {
+		return l.missing(name)
+	}
+	jsonValues, err := ExpandJson(value)
+	if err != nil {
+		return "", fmt.Errorf("parameter %s is not json: %w", name, err)
+	}
+	jsonValue, ok := jsonValues[key]
+	if !ok {
+		return l.missing(name + " " + key)
+	}
+	return jsonValue, nil
+}
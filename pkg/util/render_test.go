@@ -0,0 +1,127 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+func newRenderTestSSM() (*AWSSSM, *memorySSM) {
+	memory := newMemorySSM()
+	memory.add("/dev/app/db_host", "localhost", "String", "", "", nil)
+	memory.add("/dev/app/db_port", "5432", "String", "", "", nil)
+	memory.add("/dev/app/sub/name", "subvalue", "String", "", "", nil)
+	memory.add("jsonparam1", `{"key": "jsonvalue", "num": 1}`, "SecureString", "", "", nil)
+	for index := 0; index < 12; index++ {
+		memory.add(fmt.Sprintf("/many/param%02d", index), fmt.Sprintf("value%02d", index), "String", "", "", nil)
+	}
+	// version 2 has the label release, version 3 is current
+	memory.add("/versioned/db_host", "db.first", "String", "", "", nil)
+	for _, value := range []string{"db.release", "db.current"} {
+		memory.PutParameter(&ssm.PutParameterInput{Name: aws.String("/versioned/db_host"), Value: aws.String(value),
+			Type: aws.String("String"), Overwrite: aws.Bool(true)})
+	}
+	memory.history["/versioned/db_host"][1].Labels = aws.StringSlice([]string{"release"})
+	ssmClient := NewSSM()
+	ssmClient.SSM = memory
+	return ssmClient, memory
+}
+
+func Test_RenderTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		options  RenderOptions
+		want     string
+		wantErr  error
+		getCalls int
+	}{
+		{
+			name:     "ssm",
+			text:     `host={{ ssm "/dev/app/db_host" }}:{{ ssm "/dev/app/db_port" }}`,
+			want:     "host=localhost:5432",
+			getCalls: 1,
+		},
+		{
+			name:    "ssmPath",
+			text:    `{{ range $key, $value := ssmPath "/dev/app" }}{{ $key }}={{ $value }};{{ end }}`,
+			options: RenderOptions{Recursive: true},
+			want:    "db_host=localhost;db_port=5432;sub/name=subvalue;",
+		},
+		{
+			name: "ssmPath not recursive",
+			text: `{{ range $key, $value := ssmPath "/dev/app" }}{{ $key }}={{ $value }};{{ end }}`,
+			want: "db_host=localhost;db_port=5432;",
+		},
+		{
+			name:     "ssmJson",
+			text:     `{{ ssmJson "jsonparam1" "key" }} {{ ssmJson "jsonparam1" "num" }}`,
+			want:     "jsonvalue 1",
+			getCalls: 1,
+		},
+		{
+			name: "batched",
+			text: `{{ ssm "/many/param00" }} {{ ssm "/many/param01" }} {{ ssm "/many/param02" }} {{ ssm "/many/param03" }} ` +
+				`{{ ssm "/many/param04" }} {{ ssm "/many/param05" }} {{ ssm "/many/param06" }} {{ ssm "/many/param07" }} ` +
+				`{{ ssm "/many/param08" }} {{ ssm "/many/param09" }} {{ ssm "/many/param10" }} {{ ssm "/many/param11" }}`,
+			want:     "value00 value01 value02 value03 value04 value05 value06 value07 value08 value09 value10 value11",
+			getCalls: 2,
+		},
+		{
+			name:     "conditional name is read after the first pass",
+			text:     `{{ if eq (ssm "/dev/app/db_port") "5432" }}{{ ssm "/dev/app/db_host" }}{{ end }}`,
+			want:     "localhost",
+			getCalls: 2,
+		},
+		{
+			name:     "version and label selector",
+			text:     `{{ ssm "/versioned/db_host:1" }} {{ ssm "/versioned/db_host:release" }} {{ ssm "/versioned/db_host" }} {{ ssmJson "jsonparam1:1" "key" }}`,
+			want:     "db.first db.release db.current jsonvalue",
+			getCalls: 4,
+		},
+		{
+			name:    "missing version",
+			text:    `{{ ssm "/versioned/db_host:9" }}`,
+			wantErr: ErrTemplateParameter,
+		},
+		{
+			name:    "missing",
+			text:    `{{ ssm "/dev/app/missing" }}`,
+			wantErr: ErrTemplateParameter,
+		},
+		{
+			name:    "missing json key",
+			text:    `{{ ssmJson "jsonparam1" "missing" }}`,
+			wantErr: ErrTemplateParameter,
+		},
+		{
+			name:    "missing path",
+			text:    `{{ range ssmPath "/dev/missing" }}x{{ end }}`,
+			wantErr: ErrTemplateParameter,
+		},
+		{
+			name:    "missing with default",
+			text:    `{{ ssm "/dev/app/missing" }}|{{ ssmJson "jsonparam1" "missing" }}|{{ range ssmPath "/dev/missing" }}x{{ end }}`,
+			options: RenderOptions{Default: "none", UseDefault: true},
+			want:    "none|none|",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ssmClient, memory := newRenderTestSSM()
+			got, err := ssmClient.RenderTemplate(tt.name, tt.text, tt.options)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected error %v but got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected '%s' but got '%s'", tt.want, got)
+			}
+			if tt.getCalls != 0 && memory.getCalls != tt.getCalls {
+				t.Errorf("Expected %d calls of GetParameters but got %d", tt.getCalls, memory.getCalls)
+			}
+		})
+	}
+}
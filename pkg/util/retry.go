@@ -0,0 +1,188 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DefaultMaxAttempts is the number of calls to ssm including the first one, before giving up
+	DefaultMaxAttempts = 8
+	// readsPerSecond and writesPerSecond are the default throughput limits of ssm for GetParameter* and PutParameter
+	readsPerSecond  = 40
+	writesPerSecond = 3
+)
+
+var ErrRetriesExhausted = errors.New("Gave up retrying")
+
+// sleep and now are replaced in tests
+var (
+	sleep = time.Sleep
+	now   = time.Now
+)
+
+// RetryPolicy retries throttled and retryable ssm calls with exponential backoff and full jitter
+type RetryPolicy struct {
+	// MaxAttempts is the number of calls including the first one, 1 disables retries
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// DefaultRetryPolicy is used by clients created with NewSSM and NewSSMWithOptions
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: DefaultMaxAttempts,
+	BaseDelay:   200 * time.Millisecond,
+	MaxDelay:    20 * time.Second,
+}
+
+// backoff returns a random delay up to BaseDelay doubled for each attempt, capped at MaxDelay
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	limit := p.MaxDelay
+	if attempt < 32 && p.BaseDelay<<(attempt-1) < limit {
+		limit = p.BaseDelay << (attempt - 1)
+	}
+	if limit <= 0 {
+		return 0
+	}
+	return rand.N(limit + 1)
+}
+
+// tokenBucket limits the calls per second on the client side. The rate is halved when ssm throttles
+// and recovers slowly on success, so parallel runs share the throughput instead of retrying all at once.
+// A nil bucket does not limit.
+type tokenBucket struct {
+	mutex   sync.Mutex
+	rate    float64
+	maxRate float64
+	minRate float64
+	tokens  float64
+	last    time.Time
+}
+
+func newTokenBucket(perSecond float64) *tokenBucket {
+	return &tokenBucket{
+		rate:    perSecond,
+		maxRate: perSecond,
+		minRate: perSecond / 20,
+		tokens:  perSecond,
+		last:    now(),
+	}
+}
+
+// wait takes a token, waiting until there is one. Tokens can be taken in advance, so waiting callers queue up.
+func (b *tokenBucket) wait() {
+	if b == nil {
+		return
+	}
+	b.mutex.Lock()
+	current := now()
+	b.tokens += current.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.rate {
+		b.tokens = b.rate
+	}
+	b.last = current
+	b.tokens--
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mutex.Unlock()
+	if delay > 0 {
+		sleep(delay)
+	}
+}
+
+func (b *tokenBucket) throttled() {
+	if b == nil {
+		return
+	}
+	b.mutex.Lock()
+	b.rate /= 2
+	if b.rate < b.minRate {
+		b.rate = b.minRate
+	}
+	b.mutex.Unlock()
+}
+
+func (b *tokenBucket) succeeded() {
+	if b == nil {
+		return
+	}
+	b.mutex.Lock()
+	b.rate += b.maxRate / 20
+	if b.rate > b.maxRate {
+		b.rate = b.maxRate
+	}
+	b.mutex.Unlock()
+}
+
+// classifyError returns if an error of ssm is worth retrying, and if it is a throttling error
+func classifyError(err error) (bool, bool) {
+	var awsErr awserr.Error
+	if !errors.As(err, &awsErr) {
+		return false, false
+	}
+	if request.IsErrorThrottle(awsErr) || awsErr.Code() == ssm.ErrCodeTooManyUpdates {
+		return true, true
+	}
+	var failure awserr.RequestFailure
+	if errors.As(err, &failure) {
+		if failure.StatusCode() == 429 {
+			return true, true
+		}
+		if failure.StatusCode() >= 500 {
+			return true, false
+		}
+	}
+	return request.IsErrorRetryable(awsErr), false
+}
+
+// read calls a reading ssm operation with the rate limit for reads and the retry policy
+func (f *AWSSSM) read(operation string, call func() error) error {
+	return f.retry(f.reads, operation, call)
+}
+
+// write calls a writing ssm operation with the rate limit for writes and the retry policy
+func (f *AWSSSM) write(operation string, call func() error) error {
+	return f.retry(f.writes, operation, call)
+}
+
+func (f *AWSSSM) retry(bucket *tokenBucket, operation string, call func() error) error {
+	policy := f.Retry
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	for attempt := 1; ; attempt++ {
+		bucket.wait()
+		err := call()
+		if err == nil {
+			bucket.succeeded()
+			return nil
+		}
+		retryable, throttle := classifyError(err)
+		if throttle {
+			bucket.throttled()
+		}
+		if !retryable {
+			return err
+		}
+		if attempt >= policy.MaxAttempts {
+			if attempt == 1 {
+				return err
+			}
+			return fmt.Errorf("%w: %s after %d attempts: %w", ErrRetriesExhausted, operation, attempt, err)
+		}
+		delay := policy.backoff(attempt)
+		log.Warn().Msgf("%s failed, retrying in %s (attempt %d of %d): %s", operation, delay, attempt, policy.MaxAttempts, err.Error())
+		sleep(delay)
+	}
+}
@@ -0,0 +1,182 @@
+package util
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// fakeClock replaces sleep and now, sleeping advances the clock
+func fakeClock(t *testing.T) *[]time.Duration {
+	sleeps := make([]time.Duration, 0)
+	current := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	originalSleep, originalNow := sleep, now
+	sleep = func(delay time.Duration) {
+		sleeps = append(sleeps, delay)
+		current = current.Add(delay)
+	}
+	now = func() time.Time { return current }
+	t.Cleanup(func() {
+		sleep, now = originalSleep, originalNow
+	})
+	return &sleeps
+}
+
+// failingSSM fails the first calls of PutParameter and GetParameters with an error
+type failingSSM struct {
+	*memorySSM
+	failures int
+	err      error
+	calls    int
+}
+
+func (s *failingSSM) fail() error {
+	s.calls++
+	if s.calls <= s.failures {
+		return s.err
+	}
+	return nil
+}
+
+func (s *failingSSM) PutParameter(input *ssm.PutParameterInput) (*ssm.PutParameterOutput, error) {
+	if err := s.fail(); err != nil {
+		return nil, err
+	}
+	return s.memorySSM.PutParameter(input)
+}
+
+func (s *failingSSM) GetParameters(input *ssm.GetParametersInput) (*ssm.GetParametersOutput, error) {
+	if err := s.fail(); err != nil {
+		return nil, err
+	}
+	return s.memorySSM.GetParameters(input)
+}
+
+func Test_RetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for attempt := 1; attempt <= 40; attempt++ {
+		limit := time.Second
+		if attempt <= 4 {
+			limit = 100 * time.Millisecond << (attempt - 1)
+		}
+		for try := 0; try < 20; try++ {
+			if delay := policy.backoff(attempt); delay < 0 || delay > limit {
+				t.Fatalf("Attempt %d: expected a delay up to %s but got %s", attempt, limit, delay)
+			}
+		}
+	}
+}
+
+func Test_retry(t *testing.T) {
+	throttle := awserr.New("ThrottlingException", "Rate exceeded", nil)
+	tests := []struct {
+		name        string
+		failures    int
+		err         error
+		maxAttempts int
+		wantCalls   int
+		wantErr     error
+		wantCode    string
+	}{
+		{name: "throttled then saved", failures: 2, err: throttle, maxAttempts: 5, wantCalls: 3},
+		{name: "too many updates", failures: 1, err: awserr.New(ssm.ErrCodeTooManyUpdates, "", nil), maxAttempts: 5, wantCalls: 2},
+		{name: "server error", failures: 1, err: awserr.NewRequestFailure(awserr.New("InternalServerError", "", nil), 500, "id"), maxAttempts: 5, wantCalls: 2},
+		{name: "gives up", failures: 10, err: throttle, maxAttempts: 3, wantCalls: 3, wantErr: ErrRetriesExhausted, wantCode: "ThrottlingException"},
+		{name: "retries disabled", failures: 1, err: throttle, maxAttempts: 1, wantCalls: 1, wantCode: "ThrottlingException"},
+		{name: "not retryable", failures: 1, err: awserr.New(ssm.ErrCodeParameterLimitExceeded, "", nil), maxAttempts: 5, wantCalls: 1, wantCode: ssm.ErrCodeParameterLimitExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClock(t)
+			failing := &failingSSM{memorySSM: newMemorySSM(), failures: tt.failures, err: tt.err}
+			ssmClient := &AWSSSM{
+				SSM:    failing,
+				Retry:  RetryPolicy{MaxAttempts: tt.maxAttempts, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second},
+				writes: newTokenBucket(writesPerSecond),
+			}
+			err := ssmClient.SaveParameter("/app/name", "value", "String")
+			if failing.calls != tt.wantCalls {
+				t.Errorf("Expected %d calls but got %d", tt.wantCalls, failing.calls)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected %v but got %v", tt.wantErr, err)
+			}
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("Expected no error but got %v", err)
+				}
+				if failing.values["/app/name"] != "value" {
+					t.Errorf("Expected the value to be saved")
+				}
+				return
+			}
+			var awsErr awserr.Error
+			if !errors.As(err, &awsErr) || awsErr.Code() != tt.wantCode {
+				t.Errorf("Expected an aws error %s but got %v", tt.wantCode, err)
+			}
+		})
+	}
+}
+
+func Test_tokenBucket(t *testing.T) {
+	sleeps := fakeClock(t)
+	bucket := newTokenBucket(4)
+
+	// the first second is a burst
+	for call := 0; call < 4; call++ {
+		bucket.wait()
+	}
+	if len(*sleeps) != 0 {
+		t.Fatalf("Expected no waiting within the burst but got %v", *sleeps)
+	}
+	bucket.wait()
+	if len(*sleeps) != 1 || (*sleeps)[0] != 250*time.Millisecond {
+		t.Fatalf("Expected to wait for one token at 4 per second but got %v", *sleeps)
+	}
+
+	bucket.throttled()
+	if bucket.rate != 2 {
+		t.Errorf("Expected the rate to be halved to 2 but got %f", bucket.rate)
+	}
+	for call := 0; call < 10; call++ {
+		bucket.throttled()
+	}
+	if bucket.rate != bucket.minRate {
+		t.Errorf("Expected the rate to stop at %f but got %f", bucket.minRate, bucket.rate)
+	}
+	for call := 0; call < 40; call++ {
+		bucket.succeeded()
+	}
+	if bucket.rate != 4 {
+		t.Errorf("Expected the rate to recover to 4 but got %f", bucket.rate)
+	}
+
+	var unlimited *tokenBucket
+	unlimited.wait()
+	unlimited.throttled()
+	unlimited.succeeded()
+}
+
+func Test_SaveParametersThrottled(t *testing.T) {
+	fakeClock(t)
+	failing := &failingSSM{memorySSM: newMemorySSM(), failures: 3, err: awserr.New("ThrottlingException", "Rate exceeded", nil)}
+	ssmClient := &AWSSSM{
+		SSM:    failing,
+		Retry:  DefaultRetryPolicy,
+		reads:  newTokenBucket(readsPerSecond),
+		writes: newTokenBucket(writesPerSecond),
+	}
+	params := map[string]string{"NAME1": "value1", "NAME2": "value2", "NAME3": "value3"}
+	types := map[string]string{"NAME1": "String", "NAME2": "String", "NAME3": "String"}
+	if err := ssmClient.SaveParameters(params, types, "/app", Flags{}); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	for name, value := range params {
+		if got := failing.values["/app/"+name]; got != value {
+			t.Errorf("Expected /app/%s to be %s but got %s", name, value, got)
+		}
+	}
+}
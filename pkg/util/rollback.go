@@ -0,0 +1,125 @@
+package util
+
+import (
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+var ErrNoRollbackTarget = errors.New("Either a time or a label is needed to roll back to")
+
+// RollbackOptions selects the versions to roll back to, either the versions in effect at a time or with a label
+type RollbackOptions struct {
+	To        time.Time
+	Label     string
+	Recursive bool
+	// DeleteNewer deletes parameters which did not exist at the time, or have no version with the label.
+	// Parameters whose first versions were purged are kept, they may have existed.
+	DeleteNewer bool
+}
+
+// RollbackPlan holds the current and the target values by full name. Names which are not in the target are deleted.
+type RollbackPlan struct {
+	Current     map[string]string
+	Target      map[string]string
+	TargetTypes map[string]string
+	CurrentType map[string]string
+	Delete      []string
+}
+
+// Diff compares the current values with the values after the rollback
+func (p RollbackPlan) Diff() []DiffEntry {
+	return DiffParams(p.Current, p.Target)
+}
+
+// Restore returns the values which are changed by the rollback
+func (p RollbackPlan) Restore() map[string]string {
+	restore := make(map[string]string)
+	for name, value := range p.Target {
+		if value != p.Current[name] || p.TargetTypes[name] != p.CurrentType[name] {
+			restore[name] = value
+		}
+	}
+	return restore
+}
+
+// PlanRollback finds the version to roll back to for every parameter of a list of names and paths
+func (f *AWSSSM) PlanRollback(paramstring *string, options RollbackOptions) (RollbackPlan, error) {
+	plan := RollbackPlan{
+		Current:     make(map[string]string),
+		Target:      make(map[string]string),
+		TargetTypes: make(map[string]string),
+		CurrentType: make(map[string]string),
+		Delete:      make([]string, 0),
+	}
+	if options.To.IsZero() && options.Label == "" {
+		return plan, ErrNoRollbackTarget
+	}
+
+	names, err := f.ResolveParameterNames(paramstring, Flags{Recursive: options.Recursive})
+	if err != nil {
+		return plan, err
+	}
+	for _, name := range names {
+		versions, err := f.GetParameterHistory(name)
+		if err != nil {
+			return plan, err
+		}
+		if len(versions) == 0 {
+			continue
+		}
+		current := versions[len(versions)-1]
+		plan.Current[name] = current.Value
+		plan.CurrentType[name] = current.Type
+
+		target, ok := rollbackVersion(versions, options)
+		// ssm keeps only the last 100 versions, without version 1 the older ones may have been purged
+		created := versions[0].Version == 1
+		if ok {
+			plan.Target[name] = target.Value
+			plan.TargetTypes[name] = target.Type
+		} else if options.DeleteNewer && created {
+			plan.Delete = append(plan.Delete, name)
+		} else {
+			if options.DeleteNewer {
+				log.Warn().Msgf("No version to roll back to, older versions were purged, keeping: %s", name)
+			} else {
+				log.Warn().Msgf("No version to roll back to, keeping: %s", name)
+			}
+			plan.Target[name] = current.Value
+			plan.TargetTypes[name] = current.Type
+		}
+	}
+	return plan, nil
+}
+
+// rollbackVersion returns the version with the label, or the last version modified before or at the time
+func rollbackVersion(versions []ParameterVersion, options RollbackOptions) (ParameterVersion, bool) {
+	if options.Label != "" {
+		return labelledVersion(versions, options.Label)
+	}
+	var target ParameterVersion
+	found := false
+	for _, version := range versions {
+		if !version.LastModifiedDate.After(options.To) {
+			target = version
+			found = true
+		}
+	}
+	return target, found
+}
+
+// ApplyRollback saves the changed values with their type of the target version, and deletes the newer parameters
+func (f *AWSSSM) ApplyRollback(plan RollbackPlan) error {
+	restore := plan.Restore()
+	if len(restore) > 0 {
+		if err := f.SaveParameters(restore, plan.TargetTypes, "", Flags{}); err != nil {
+			return err
+		}
+	}
+	if len(plan.Delete) > 0 {
+		return f.DeleteParameters(plan.Delete)
+	}
+	return nil
+}
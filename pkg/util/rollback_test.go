@@ -0,0 +1,134 @@
+package util
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func Test_PlanRollback(t *testing.T) {
+	tests := []struct {
+		name        string
+		options     RollbackOptions
+		wantRestore map[string]string
+		wantDelete  []string
+	}{
+		{
+			name:        "to time keeps newer",
+			options:     RollbackOptions{To: time.Date(2024, 1, 1, 2, 30, 0, 0, time.UTC), Recursive: true},
+			wantRestore: map[string]string{"/app/db_password": "first"},
+			wantDelete:  []string{},
+		},
+		{
+			name:        "to time deletes newer",
+			options:     RollbackOptions{To: time.Date(2024, 1, 1, 2, 30, 0, 0, time.UTC), Recursive: true, DeleteNewer: true},
+			wantRestore: map[string]string{"/app/db_password": "first"},
+			wantDelete:  []string{"/app/new"},
+		},
+		{
+			name:        "to label",
+			options:     RollbackOptions{Label: "prod", Recursive: true, DeleteNewer: true},
+			wantRestore: map[string]string{"/app/db_password": "second"},
+			wantDelete:  []string{"/app/db_user", "/app/new"},
+		},
+		{
+			name:        "to current time",
+			options:     RollbackOptions{To: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), Recursive: true, DeleteNewer: true},
+			wantRestore: map[string]string{},
+			wantDelete:  []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ssmClient, _ := newHistoryTestSSM()
+			if err := ssmClient.SaveParameter("/app/new", "new", ""); err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			paramstring := "/app"
+			plan, err := ssmClient.PlanRollback(&paramstring, tt.options)
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			if got := plan.Restore(); !reflect.DeepEqual(got, tt.wantRestore) {
+				t.Errorf("Restore() = %v, want %v", got, tt.wantRestore)
+			}
+			if !reflect.DeepEqual(plan.Delete, tt.wantDelete) {
+				t.Errorf("Delete = %v, want %v", plan.Delete, tt.wantDelete)
+			}
+		})
+	}
+
+	ssmClient, _ := newHistoryTestSSM()
+	paramstring := "/app"
+	if _, err := ssmClient.PlanRollback(&paramstring, RollbackOptions{}); !errors.Is(err, ErrNoRollbackTarget) {
+		t.Errorf("Expected ErrNoRollbackTarget but got %v", err)
+	}
+}
+
+func Test_PlanRollbackPurgedVersions(t *testing.T) {
+	ssmClient, memory := newHistoryTestSSM()
+	for _, value := range []string{"v1", "v2", "v3", "v4", "v5", "v6"} {
+		if err := ssmClient.SaveParameter("/app/purged", value, ""); err != nil {
+			t.Fatalf("Expected no error but got %v", err)
+		}
+	}
+	// ssm only keeps the last 100 versions, here the history starts at version 5
+	memory.history["/app/purged"] = memory.history["/app/purged"][4:]
+
+	paramstring := "/app"
+	for _, options := range []RollbackOptions{
+		{To: time.Date(2024, 1, 1, 2, 30, 0, 0, time.UTC), Recursive: true, DeleteNewer: true},
+		{Label: "prod", Recursive: true, DeleteNewer: true},
+	} {
+		plan, err := ssmClient.PlanRollback(&paramstring, options)
+		if err != nil {
+			t.Fatalf("Expected no error but got %v", err)
+		}
+		for _, name := range plan.Delete {
+			if name == "/app/purged" {
+				t.Errorf("Expected /app/purged with purged versions not to be deleted for %+v", options)
+			}
+		}
+		if plan.Target["/app/purged"] != "v6" {
+			t.Errorf("Expected /app/purged to be kept at v6 but got %q", plan.Target["/app/purged"])
+		}
+	}
+}
+
+func Test_ApplyRollback(t *testing.T) {
+	ssmClient, memory := newHistoryTestSSM()
+	if err := ssmClient.SaveParameter("/app/new", "new", ""); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	paramstring := "/app"
+	plan, err := ssmClient.PlanRollback(&paramstring, RollbackOptions{
+		To:          time.Date(2024, 1, 1, 2, 30, 0, 0, time.UTC),
+		Recursive:   true,
+		DeleteNewer: true,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if err := ssmClient.ApplyRollback(plan); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	if got := memory.values["/app/db_password"]; got != "first" {
+		t.Errorf("Expected /app/db_password to be rolled back to first but got %s", got)
+	}
+	if got := aws.StringValue(memory.params["/app/db_password"].Type); got != "SecureString" {
+		t.Errorf("Expected /app/db_password to keep its type SecureString but got %s", got)
+	}
+	if got := len(memory.history["/app/db_password"]); got != 4 {
+		t.Errorf("Expected the rollback to add version 4 but got %d versions", got)
+	}
+	if _, ok := memory.values["/app/new"]; ok {
+		t.Errorf("Expected /app/new to be deleted")
+	}
+	if got := memory.values["/app/db_user"]; got != "admin" {
+		t.Errorf("Expected /app/db_user to be unchanged but got %s", got)
+	}
+}
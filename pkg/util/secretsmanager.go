@@ -0,0 +1,236 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/rs/zerolog/log"
+)
+
+// SecretsPrefix marks a name in the list of names and paths as a secret of Secrets Manager, as sm://prod/db-credentials
+const SecretsPrefix = "sm://"
+
+var (
+	ErrNoSecretsManager = errors.New("Secrets Manager is not configured")
+	ErrBinarySecret     = errors.New("Binary secrets are not supported")
+	ErrSecretsWithTags  = errors.New("Secrets of Secrets Manager can not be selected by tags")
+	// ErrSecretNotSupported is returned for sm:// names given to features of SSM, like delete, labels and history
+	ErrSecretNotSupported = errors.New("Only supported for SSM parameters, not for secrets of Secrets Manager")
+)
+
+// splitSecretIds separates the secrets of Secrets Manager from the names and paths of SSM.
+// With the SecretsManager flag all entries are secrets.
+func splitSecretIds(params []string, flags Flags) ([]string, []string) {
+	secretIds := make([]string, 0)
+	rest := make([]string, 0, len(params))
+	for _, param := range params {
+		if flags.SecretsManager || strings.HasPrefix(param, SecretsPrefix) {
+			secretIds = append(secretIds, strings.TrimPrefix(param, SecretsPrefix))
+		} else {
+			rest = append(rest, param)
+		}
+	}
+	return secretIds, rest
+}
+
+// rejectSecrets checks that none of the names and paths is a secret, before they are passed to SSM
+func rejectSecrets(params []string) error {
+	for _, param := range params {
+		if strings.HasPrefix(param, SecretsPrefix) {
+			return fmt.Errorf("%w: %s", ErrSecretNotSupported, param)
+		}
+	}
+	return nil
+}
+
+// secretsManager calls a Secrets Manager operation with the retry policy. Its limits are much higher than those
+// of SSM, so it is not rate limited on the client side.
+func (f *AWSSSM) secretsManager(operation string, call func() error) error {
+	if f.SecretsManager == nil {
+		return ErrNoSecretsManager
+	}
+	return f.retry(nil, operation, call)
+}
+
+func newSecretParameter(name *string, value *string) (Parameter, error) {
+	if value == nil {
+		return Parameter{}, fmt.Errorf("%w: %s", ErrBinarySecret, aws.StringValue(name))
+	}
+	// secrets are sensitive like SecureStrings, so they are written to a Secret with --split-by-type
+	return Parameter{
+		Name:           aws.StringValue(name),
+		Value:          aws.StringValue(value),
+		Type:           ssm.ParameterTypeSecureString,
+		SecretsManager: true,
+	}, nil
+}
+
+// GetSecrets reads secrets by name or arn, keyed by the output name like GetParameters.
+// A single secret is read with GetSecretValue, so it only needs that permission.
+func (f *AWSSSM) GetSecrets(secretIds []string, flags Flags) (map[string]Parameter, error) {
+	params := make(map[string]Parameter)
+	found := make([]Parameter, 0, len(secretIds))
+	if len(secretIds) == 1 {
+		param, err := f.getSecret(secretIds[0])
+		if err != nil {
+			return params, err
+		}
+		found = append(found, param)
+	} else {
+		// BatchGetSecretValue supports at max 20 secrets
+		for _, chunk := range chunkParamNames(aws.StringSlice(secretIds), 20) {
+			chunkParams, err := f.getSecretsChunk(chunk)
+			if err != nil {
+				return params, err
+			}
+			found = append(found, chunkParams...)
+		}
+	}
+	for _, param := range found {
+		name, _, _ := getNameAndValue(param, flags)
+		log.Debug().Msgf("Secret: %s", name)
+		params[name] = param
+	}
+	return params, nil
+}
+
+func (f *AWSSSM) getSecret(secretId string) (Parameter, error) {
+	input := &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(secretId),
+	}
+	var output *secretsmanager.GetSecretValueOutput
+	err := f.secretsManager("GetSecretValue", func() (err error) {
+		output, err = f.SecretsManager.GetSecretValue(input)
+		return err
+	})
+	if err != nil {
+		var awsErr awserr.Error
+		if errors.As(err, &awsErr) && awsErr.Code() == secretsmanager.ErrCodeResourceNotFoundException {
+			return Parameter{}, fmt.Errorf("%w: %s%s", ErrNameNotFound, SecretsPrefix, secretId)
+		}
+		return Parameter{}, err
+	}
+	return newSecretParameter(output.Name, output.SecretString)
+}
+
+func (f *AWSSSM) getSecretsChunk(chunk []*string) ([]Parameter, error) {
+	params := make([]Parameter, 0, len(chunk))
+	var nextToken *string
+	for {
+		input := &secretsmanager.BatchGetSecretValueInput{
+			SecretIdList: chunk,
+			NextToken:    nextToken,
+		}
+		var output *secretsmanager.BatchGetSecretValueOutput
+		err := f.secretsManager("BatchGetSecretValue", func() (err error) {
+			output, err = f.SecretsManager.BatchGetSecretValue(input)
+			return err
+		})
+		if err != nil {
+			return params, err
+		}
+		// secrets which could not be read are reported per secret, unlike missing names in SSM they are an error
+		for _, secretErr := range output.Errors {
+			if aws.StringValue(secretErr.ErrorCode) == secretsmanager.ErrCodeResourceNotFoundException {
+				return params, fmt.Errorf("%w: %s%s", ErrNameNotFound, SecretsPrefix, aws.StringValue(secretErr.SecretId))
+			}
+			return params, fmt.Errorf("%s%s: %s: %s", SecretsPrefix, aws.StringValue(secretErr.SecretId),
+				aws.StringValue(secretErr.ErrorCode), aws.StringValue(secretErr.Message))
+		}
+		for _, secret := range output.SecretValues {
+			param, err := newSecretParameter(secret.Name, secret.SecretString)
+			if err != nil {
+				return params, err
+			}
+			params = append(params, param)
+		}
+		nextToken = output.NextToken
+		if nextToken == nil {
+			return params, nil
+		}
+	}
+}
+
+func (f *AWSSSM) SaveSecretFromFile(fileName string, secretId string, flags Flags) error {
+	params, _, err := f.ReadTypedParametersFromFile(fileName, "", flags)
+	if err != nil {
+		log.Error().Msg(err.Error())
+		return err
+	}
+	return f.SaveSecret(params, secretId, flags)
+}
+
+// SaveSecret writes the params as one JSON secret, which get reads back with --injson.
+// The secret is created if it does not exist, with the kms key and tags of the flags.
+func (f *AWSSSM) SaveSecret(params map[string]string, secretId string, flags Flags) error {
+	value, err := json.MarshalIndent(params, "", "  ")
+	if err != nil {
+		return err
+	}
+	if flags.Dry {
+		fmt.Printf("### Dry run, not saving, this would have been set as secret %s:\n", secretId)
+		fmt.Println(string(value))
+		return nil
+	}
+
+	input := &secretsmanager.PutSecretValueInput{
+		SecretId:     aws.String(secretId),
+		SecretString: aws.String(string(value)),
+	}
+	err = f.secretsManager("PutSecretValue", func() error {
+		_, err := f.SecretsManager.PutSecretValue(input)
+		return err
+	})
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) && awsErr.Code() == secretsmanager.ErrCodeResourceNotFoundException {
+		err = f.createSecret(secretId, string(value), flags)
+	} else if err == nil {
+		err = f.tagSecret(secretId, flags.Tags)
+	}
+	if err != nil {
+		return err
+	}
+	log.Info().Msgf("Saved %d values to secret %s", len(params), secretId)
+	f.clearCache()
+	return nil
+}
+
+func (f *AWSSSM) createSecret(name string, value string, flags Flags) error {
+	input := &secretsmanager.CreateSecretInput{
+		Name:         aws.String(name),
+		SecretString: aws.String(value),
+	}
+	if flags.KmsKeyId != "" {
+		input.KmsKeyId = aws.String(flags.KmsKeyId)
+	}
+	for _, key := range GetSortedNamesFromParams(flags.Tags) {
+		input.Tags = append(input.Tags, &secretsmanager.Tag{Key: aws.String(key), Value: aws.String(flags.Tags[key])})
+	}
+	return f.secretsManager("CreateSecret", func() error {
+		_, err := f.SecretsManager.CreateSecret(input)
+		return err
+	})
+}
+
+// tagSecret adds the tags to an existing secret, the kms key of an existing secret is kept
+func (f *AWSSSM) tagSecret(secretId string, tags map[string]string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	input := &secretsmanager.TagResourceInput{
+		SecretId: aws.String(secretId),
+	}
+	for _, key := range GetSortedNamesFromParams(tags) {
+		input.Tags = append(input.Tags, &secretsmanager.Tag{Key: aws.String(key), Value: aws.String(tags[key])})
+	}
+	return f.secretsManager("TagResource", func() error {
+		_, err := f.SecretsManager.TagResource(input)
+		return err
+	})
+}
@@ -0,0 +1,324 @@
+package util
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+)
+
+// memorySecretsManager is a mock which keeps the secrets in memory
+type memorySecretsManager struct {
+	secretsmanageriface.SecretsManagerAPI
+	secrets    map[string]string
+	keyIds     map[string]string
+	tags       map[string]map[string]string
+	getCalls   int
+	batchCalls int
+}
+
+func newMemorySecretsManager(secrets map[string]string) *memorySecretsManager {
+	return &memorySecretsManager{
+		secrets: secrets,
+		keyIds:  make(map[string]string),
+		tags:    make(map[string]map[string]string),
+	}
+}
+
+func notFound(secretId *string) error {
+	return awserr.New(secretsmanager.ErrCodeResourceNotFoundException, "not found: "+aws.StringValue(secretId), nil)
+}
+
+func (m *memorySecretsManager) GetSecretValue(input *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
+	m.getCalls++
+	value, ok := m.secrets[aws.StringValue(input.SecretId)]
+	if !ok {
+		return nil, notFound(input.SecretId)
+	}
+	return &secretsmanager.GetSecretValueOutput{Name: input.SecretId, SecretString: aws.String(value)}, nil
+}
+
+func (m *memorySecretsManager) BatchGetSecretValue(input *secretsmanager.BatchGetSecretValueInput) (*secretsmanager.BatchGetSecretValueOutput, error) {
+	m.batchCalls++
+	output := &secretsmanager.BatchGetSecretValueOutput{}
+	for _, secretId := range input.SecretIdList {
+		value, ok := m.secrets[aws.StringValue(secretId)]
+		if !ok {
+			output.Errors = append(output.Errors, &secretsmanager.APIErrorType{
+				SecretId:  secretId,
+				ErrorCode: aws.String(secretsmanager.ErrCodeResourceNotFoundException),
+			})
+			continue
+		}
+		output.SecretValues = append(output.SecretValues, &secretsmanager.SecretValueEntry{Name: secretId, SecretString: aws.String(value)})
+	}
+	return output, nil
+}
+
+func (m *memorySecretsManager) PutSecretValue(input *secretsmanager.PutSecretValueInput) (*secretsmanager.PutSecretValueOutput, error) {
+	if _, ok := m.secrets[aws.StringValue(input.SecretId)]; !ok {
+		return nil, notFound(input.SecretId)
+	}
+	m.secrets[aws.StringValue(input.SecretId)] = aws.StringValue(input.SecretString)
+	return &secretsmanager.PutSecretValueOutput{}, nil
+}
+
+func (m *memorySecretsManager) CreateSecret(input *secretsmanager.CreateSecretInput) (*secretsmanager.CreateSecretOutput, error) {
+	m.secrets[aws.StringValue(input.Name)] = aws.StringValue(input.SecretString)
+	m.keyIds[aws.StringValue(input.Name)] = aws.StringValue(input.KmsKeyId)
+	m.addTags(input.Name, input.Tags)
+	return &secretsmanager.CreateSecretOutput{Name: input.Name}, nil
+}
+
+func (m *memorySecretsManager) TagResource(input *secretsmanager.TagResourceInput) (*secretsmanager.TagResourceOutput, error) {
+	m.addTags(input.SecretId, input.Tags)
+	return &secretsmanager.TagResourceOutput{}, nil
+}
+
+func (m *memorySecretsManager) addTags(secretId *string, tags []*secretsmanager.Tag) {
+	for _, tag := range tags {
+		if m.tags[aws.StringValue(secretId)] == nil {
+			m.tags[aws.StringValue(secretId)] = make(map[string]string)
+		}
+		m.tags[aws.StringValue(secretId)][aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+}
+
+func newSecretsTestSSM() (*AWSSSM, *memorySecretsManager) {
+	secrets := newMemorySecretsManager(map[string]string{
+		"prod/db-credentials": `{"user":"admin","password":"secret"}`,
+		"prod/api-key":        "key123",
+	})
+	ssmClient := NewSSMWithStore(NewMemoryStore(map[string]string{
+		"/prod/app/user": "app",
+		"/prod/app/port": "8080",
+	}))
+	ssmClient.SecretsManager = secrets
+	return ssmClient, secrets
+}
+
+func Test_GetParamsSecrets(t *testing.T) {
+	tests := []struct {
+		name      string
+		params    string
+		flags     Flags
+		want      map[string]string
+		wantErr   error
+		wantGet   int
+		wantBatch int
+	}{
+		{
+			name:    "single secret",
+			params:  "sm://prod/api-key",
+			want:    map[string]string{"api-key": "key123"},
+			wantGet: 1,
+		},
+		{
+			name:      "merged with ssm",
+			params:    "/prod/app,sm://prod/db-credentials,sm://prod/api-key",
+			flags:     Flags{Recursive: true},
+			want:      map[string]string{"user": "app", "port": "8080", "api-key": "key123", "db-credentials": `{"user":"admin","password":"secret"}`},
+			wantBatch: 1,
+		},
+		{
+			name:    "upper",
+			params:  "/prod/app,sm://prod/db-credentials",
+			flags:   Flags{Recursive: true, Upper: true},
+			want:    map[string]string{"USER": "app", "PORT": "8080", "DB-CREDENTIALS": `{"user":"admin","password":"secret"}`},
+			wantGet: 1,
+		},
+		{
+			name:    "secretsmanager flag",
+			params:  "prod/db-credentials",
+			flags:   Flags{SecretsManager: true, InJson: true},
+			want:    map[string]string{"user": "admin", "password": "secret"},
+			wantGet: 1,
+		},
+		{
+			name:    "normalized path",
+			params:  "sm://prod/api-key",
+			flags:   Flags{PrefixNormalizedPath: true},
+			want:    map[string]string{"prod_api-key": "key123"},
+			wantGet: 1,
+		},
+		{
+			name:    "missing secret",
+			params:  "sm://prod/missing",
+			wantErr: ErrNameNotFound,
+			wantGet: 1,
+		},
+		{
+			name:    "tags",
+			params:  "/prod/app,sm://prod/api-key",
+			flags:   Flags{Tags: map[string]string{"team": "payments"}},
+			wantErr: ErrSecretsWithTags,
+		},
+		{
+			name:    "secretsmanager flag with tags",
+			params:  "prod/api-key",
+			flags:   Flags{SecretsManager: true, Tags: map[string]string{"team": "payments"}},
+			wantErr: ErrSecretsWithTags,
+		},
+		{
+			name:      "missing secret in batch",
+			params:    "sm://prod/api-key,sm://prod/missing",
+			wantErr:   ErrNameNotFound,
+			wantBatch: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ssmClient, secrets := newSecretsTestSSM()
+			result, err := ssmClient.GetParams(&tt.params, tt.flags)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected error %v but got %v", tt.wantErr, err)
+			}
+			if tt.wantErr == nil && !reflect.DeepEqual(result, tt.want) {
+				t.Errorf("Expected %s but got %s", tt.want, result)
+			}
+			if secrets.getCalls != tt.wantGet || secrets.batchCalls != tt.wantBatch {
+				t.Errorf("Expected %d single and %d batch reads but got %d and %d", tt.wantGet, tt.wantBatch, secrets.getCalls, secrets.batchCalls)
+			}
+		})
+	}
+}
+
+func Test_GetSecretsType(t *testing.T) {
+	ssmClient, _ := newSecretsTestSSM()
+	params := "sm://prod/api-key"
+	result, err := ssmClient.GetParamsDetailed(&params, Flags{})
+	if err != nil {
+		t.Fatalf("Error in GetParamsDetailed: %s", err)
+	}
+	want := Parameter{Name: "prod/api-key", Value: "key123", Type: "SecureString", SecretsManager: true}
+	if result["api-key"] != want {
+		t.Errorf("Expected %v but got %v", want, result["api-key"])
+	}
+}
+
+func Test_SaveSecret(t *testing.T) {
+	tests := []struct {
+		name     string
+		secretId string
+		flags    Flags
+		want     string
+		wantKey  string
+		wantTags map[string]string
+	}{
+		{
+			name:     "create",
+			secretId: "prod/new",
+			flags:    Flags{KmsKeyId: "alias/prod", Tags: map[string]string{"team": "payments"}},
+			want:     "{\n  \"PASSWORD\": \"p=1\",\n  \"USER\": \"admin\"\n}",
+			wantKey:  "alias/prod",
+			wantTags: map[string]string{"team": "payments"},
+		},
+		{
+			name:     "update",
+			secretId: "prod/api-key",
+			flags:    Flags{Tags: map[string]string{"team": "payments"}},
+			want:     "{\n  \"PASSWORD\": \"p=1\",\n  \"USER\": \"admin\"\n}",
+			wantTags: map[string]string{"team": "payments"},
+		},
+		{
+			name:     "dry",
+			secretId: "prod/api-key",
+			flags:    Flags{Dry: true},
+			want:     "key123",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ssmClient, secrets := newSecretsTestSSM()
+			err := ssmClient.SaveSecret(map[string]string{"USER": "admin", "PASSWORD": "p=1"}, tt.secretId, tt.flags)
+			if err != nil {
+				t.Fatalf("Error in SaveSecret: %s", err)
+			}
+			if secrets.secrets[tt.secretId] != tt.want {
+				t.Errorf("Expected %s but got %s", tt.want, secrets.secrets[tt.secretId])
+			}
+			if secrets.keyIds[tt.secretId] != tt.wantKey {
+				t.Errorf("Expected key %q but got %q", tt.wantKey, secrets.keyIds[tt.secretId])
+			}
+			if len(tt.wantTags) > 0 && !reflect.DeepEqual(secrets.tags[tt.secretId], tt.wantTags) {
+				t.Errorf("Expected tags %s but got %s", tt.wantTags, secrets.tags[tt.secretId])
+			}
+		})
+	}
+}
+
+func Test_SaveSecretRoundTrip(t *testing.T) {
+	ssmClient, _ := newSecretsTestSSM()
+	values := map[string]string{"USER": "admin", "PASSWORD": "p=1"}
+	if err := ssmClient.SaveSecret(values, "prod/env", Flags{}); err != nil {
+		t.Fatalf("Error in SaveSecret: %s", err)
+	}
+	params := "sm://prod/env"
+	result, err := ssmClient.GetParams(&params, Flags{InJson: true})
+	if err != nil {
+		t.Fatalf("Error in GetParams: %s", err)
+	}
+	if !reflect.DeepEqual(result, values) {
+		t.Errorf("Expected %s but got %s", values, result)
+	}
+}
+
+func Test_SecretsWithoutSecretsManager(t *testing.T) {
+	ssmClient := NewSSMWithStore(NewMemoryStore(nil))
+	params := "sm://prod/api-key"
+	if _, err := ssmClient.GetParams(&params, Flags{}); !errors.Is(err, ErrNoSecretsManager) {
+		t.Errorf("Expected ErrNoSecretsManager but got %v", err)
+	}
+}
+
+func Test_SecretsRejectedBySSMFeatures(t *testing.T) {
+	ssmClient, secrets := newSecretsTestSSM()
+	params := "/prod/app,sm://prod/api-key"
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{
+			name: "resolve names for delete and label",
+			call: func() error {
+				_, err := ssmClient.ResolveParameterNames(&params, Flags{})
+				return err
+			},
+		},
+		{
+			name: "history",
+			call: func() error {
+				_, err := ssmClient.GetHistory(&params, Flags{})
+				return err
+			},
+		},
+		{
+			name: "history of a name",
+			call: func() error {
+				_, err := ssmClient.GetParameterHistory("sm://prod/api-key")
+				return err
+			},
+		},
+		{
+			name: "rollback",
+			call: func() error {
+				_, err := ssmClient.PlanRollback(&params, RollbackOptions{Label: "release"})
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, ErrSecretNotSupported) {
+				t.Errorf("Expected ErrSecretNotSupported but got %v", err)
+			}
+		})
+	}
+	if secrets.getCalls != 0 || secrets.batchCalls != 0 {
+		t.Errorf("Expected no reads of secrets but got %d and %d", secrets.getCalls, secrets.batchCalls)
+	}
+}
@@ -0,0 +1,46 @@
+//go:build !windows
+
+package util
+
+import (
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// forwardedSignals are passed on to a command started by RunCommand
+var forwardedSignals = []os.Signal{
+	syscall.SIGINT,
+	syscall.SIGTERM,
+	syscall.SIGHUP,
+	syscall.SIGQUIT,
+	syscall.SIGUSR1,
+	syscall.SIGUSR2,
+	syscall.SIGWINCH,
+}
+
+// terminalSignal returns true for the signals a terminal sends to its whole foreground process group,
+// like SIGINT on Ctrl-C
+func terminalSignal(sig os.Signal) bool {
+	switch sig {
+	case syscall.SIGINT, syscall.SIGQUIT, syscall.SIGHUP, syscall.SIGWINCH:
+		return true
+	}
+	return false
+}
+
+// inForegroundGroup returns true if this process runs in the foreground process group of the terminal on stdin.
+// A command started by RunCommand is then in the same group and gets the signals of the terminal directly.
+func inForegroundGroup() bool {
+	group, err := unix.IoctlGetInt(int(os.Stdin.Fd()), unix.TIOCGPGRP)
+	return err == nil && group == unix.Getpgrp()
+}
+
+// exitCode returns the exit code of a process, 128 plus the signal number if it was killed by a signal like a shell
+func exitCode(state *os.ProcessState) int {
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return state.ExitCode()
+}
@@ -0,0 +1,27 @@
+//go:build windows
+
+package util
+
+import (
+	"os"
+)
+
+// forwardedSignals are passed on to a command started by RunCommand
+var forwardedSignals = []os.Signal{
+	os.Interrupt,
+}
+
+// terminalSignal returns true for the signals the console sends to all processes attached to it, like Ctrl-C
+func terminalSignal(sig os.Signal) bool {
+	return sig == os.Interrupt
+}
+
+// inForegroundGroup returns true, a command started by RunCommand shares the console and gets its signals directly
+func inForegroundGroup() bool {
+	return true
+}
+
+// exitCode returns the exit code of a process
+func exitCode(state *os.ProcessState) int {
+	return state.ExitCode()
+}
@@ -0,0 +1,259 @@
+package util
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrNotSupported is returned for features of SSM, like history, labels and tags, on other stores
+var ErrNotSupported = errors.New("Only supported with SSM")
+
+// Store is a backend of parameters, which GetParams, SaveParameters, DeleteParameters and the web server read and write through.
+// Names are full names. Names and paths can have a :version or :label selector, stores without versions find nothing for them.
+type Store interface {
+	// GetByNames returns the parameters of the names which exist, names which do not exist are left out
+	GetByNames(names []string) ([]Parameter, error)
+	// GetTypes returns the types of the names which exist by full name, without reading or decrypting the values
+	GetTypes(names []string) (map[string]string, error)
+	// GetByPath returns the parameters below a path, with a :label selector only the versions with the label
+	GetByPath(path string, recursive bool) ([]Parameter, error)
+	// Put writes a parameter. Returns false, if the parameter exists and overwrite is false.
+	Put(param ParameterDetails, overwrite bool) (bool, error)
+	// Delete removes the parameters, names which do not exist are ignored
+	Delete(names []string) error
+	// List returns the sorted names below a path, without reading the values
+	List(path string, recursive bool) ([]string, error)
+}
+
+// NewSSMWithStore creates a client which reads and writes through a store instead of SSM
+func NewSSMWithStore(store Store) *AWSSSM {
+	return &AWSSSM{
+		Store: store,
+		Retry: DefaultRetryPolicy,
+	}
+}
+
+// store returns the Store of the client, by default SSM
+func (f *AWSSSM) store() Store {
+	if f.Store != nil {
+		return f.Store
+	}
+	return &ssmStore{client: f}
+}
+
+// requireSSM checks for features which only SSM has
+func (f *AWSSSM) requireSSM() error {
+	if f.SSM == nil {
+		return ErrNotSupported
+	}
+	return nil
+}
+
+// ssmStore is the Store of SSM, using the retry policy and rate limits of the client
+type ssmStore struct {
+	client *AWSSSM
+}
+
+func newParameter(param *ssm.Parameter) Parameter {
+	name, _ := SplitSelector(aws.StringValue(param.Name))
+	return Parameter{
+		Name:  name,
+		Value: aws.StringValue(param.Value),
+		Type:  aws.StringValue(param.Type),
+	}
+}
+
+func (s *ssmStore) GetByNames(names []string) ([]Parameter, error) {
+	params := make([]Parameter, 0, len(names))
+	// GetParameters only supports at max of 10 params
+	for _, chunk := range chunkParamNames(aws.StringSlice(names), 10) {
+		input := &ssm.GetParametersInput{
+			Names:          chunk,
+			WithDecryption: &trueBool,
+		}
+		var output *ssm.GetParametersOutput
+		err := s.client.read("GetParameters", func() (err error) {
+			output, err = s.client.SSM.GetParameters(input)
+			return err
+		})
+		if err != nil {
+			return params, err
+		}
+		log.Debug().Msgf("Retrieved Parameters: %s", output.Parameters)
+		for _, param := range output.Parameters {
+			params = append(params, newParameter(param))
+		}
+	}
+	return params, nil
+}
+
+func (s *ssmStore) GetTypes(names []string) (map[string]string, error) {
+	types := make(map[string]string)
+	for _, chunk := range chunkParamNames(aws.StringSlice(names), 10) {
+		// without decryption, so no kms:Decrypt is needed to look up the types
+		input := &ssm.GetParametersInput{
+			Names: chunk,
+		}
+		var output *ssm.GetParametersOutput
+		err := s.client.read("GetParameters", func() (err error) {
+			output, err = s.client.SSM.GetParameters(input)
+			return err
+		})
+		if err != nil {
+			return types, err
+		}
+		for _, param := range output.Parameters {
+			types[aws.StringValue(param.Name)] = aws.StringValue(param.Type)
+		}
+	}
+	return types, nil
+}
+
+func (s *ssmStore) GetByPath(selectedPath string, recursive bool) ([]Parameter, error) {
+	// a path can select the versions with a label as /path:label
+	path, label := SplitSelector(selectedPath)
+	params := make([]Parameter, 0)
+	var nextToken *string
+	for {
+		input := &ssm.GetParametersByPathInput{
+			Path:           aws.String(path),
+			Recursive:      aws.Bool(recursive),
+			WithDecryption: &trueBool,
+			NextToken:      nextToken,
+		}
+		if label != "" {
+			input.ParameterFilters = []*ssm.ParameterStringFilter{
+				{
+					Key:    aws.String("Label"),
+					Option: aws.String("Equals"),
+					Values: []*string{aws.String(label)},
+				},
+			}
+		}
+		var output *ssm.GetParametersByPathOutput
+		err := s.client.read("GetParametersByPath", func() (err error) {
+			output, err = s.client.SSM.GetParametersByPath(input)
+			return err
+		})
+		if err != nil {
+			return params, err
+		}
+		log.Debug().Msgf("Retrieved Parameters for path %s: %s", selectedPath, output.Parameters)
+		for _, param := range output.Parameters {
+			params = append(params, newParameter(param))
+		}
+
+		// if nextToken has a value, there are more parameters to fetch. maximum is 10 parameters at a time.
+		nextToken = output.NextToken
+		if nextToken == nil {
+			return params, nil
+		}
+	}
+}
+
+func (s *ssmStore) Put(param ParameterDetails, overwrite bool) (bool, error) {
+	input := &ssm.PutParameterInput{
+		Name:      aws.String(param.Name),
+		Value:     aws.String(param.Value),
+		Type:      aws.String(param.Type),
+		Overwrite: aws.Bool(overwrite),
+	}
+	if param.Type == ssm.ParameterTypeSecureString && param.KeyId != "" {
+		input.KeyId = aws.String(param.KeyId)
+	}
+	if param.Description != "" {
+		input.Description = aws.String(param.Description)
+	}
+	if param.Tier != "" {
+		input.Tier = aws.String(param.Tier)
+	}
+	if param.AllowedPattern != "" {
+		input.AllowedPattern = aws.String(param.AllowedPattern)
+	}
+	if param.DataType != "" {
+		input.DataType = aws.String(param.DataType)
+	}
+
+	var output *ssm.PutParameterOutput
+	err := s.client.write("PutParameter", func() (err error) {
+		output, err = s.client.SSM.PutParameter(input)
+		return err
+	})
+	if err != nil {
+		var awsErr awserr.Error
+		if errors.As(err, &awsErr) && awsErr.Code() == ssm.ErrCodeParameterAlreadyExists {
+			return false, nil
+		}
+		return false, err
+	}
+	log.Info().Msgf("Output: %s", output)
+
+	// tags can not be set together with overwrite in PutParameter
+	return true, s.client.AddTags(param.Name, param.Tags)
+}
+
+func (s *ssmStore) Delete(names []string) error {
+	for _, chunk := range chunkParamNames(aws.StringSlice(names), 10) {
+		input := &ssm.DeleteParametersInput{
+			Names: chunk,
+		}
+		var output *ssm.DeleteParametersOutput
+		err := s.client.write("DeleteParameters", func() (err error) {
+			output, err = s.client.SSM.DeleteParameters(input)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		for _, name := range output.DeletedParameters {
+			log.Debug().Msgf("Deleted: %s", *name)
+		}
+		for _, name := range output.InvalidParameters {
+			log.Warn().Msgf("Not deleted, does not exist: %s", *name)
+		}
+	}
+	return nil
+}
+
+func (s *ssmStore) List(path string, recursive bool) ([]string, error) {
+	option := "OneLevel"
+	if recursive {
+		option = "Recursive"
+	}
+	names := make([]string, 0)
+	var nextToken *string
+	for {
+		input := &ssm.DescribeParametersInput{
+			ParameterFilters: []*ssm.ParameterStringFilter{
+				{
+					Key:    aws.String("Path"),
+					Option: aws.String(option),
+					Values: []*string{aws.String(path)},
+				},
+			},
+			NextToken: nextToken,
+		}
+		var output *ssm.DescribeParametersOutput
+		err := s.client.read("DescribeParameters", func() (err error) {
+			output, err = s.client.SSM.DescribeParameters(input)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, meta := range output.Parameters {
+			names = append(names, aws.StringValue(meta.Name))
+		}
+		nextToken = output.NextToken
+		if nextToken == nil {
+			break
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,145 @@
+package util
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+var storeTestValues = map[string]string{
+	"/app/one":        "1",
+	"/app/two":        "2",
+	"/app/sub/nested": "3",
+	"single":          "4",
+}
+
+// newTestStores returns the same parameters in each Store implementation, the tests run against all of them
+func newTestStores() map[string]*AWSSSM {
+	memory := newMemorySSM()
+	for name, value := range storeTestValues {
+		memory.add(name, value, "String", "", "", nil)
+	}
+	ssmClient := NewSSM()
+	ssmClient.SSM = memory
+	return map[string]*AWSSSM{
+		"ssm":    ssmClient,
+		"memory": NewSSMWithStore(NewMemoryStore(storeTestValues)),
+	}
+}
+
+func Test_StoreGetParams(t *testing.T) {
+	tests := []struct {
+		params    string
+		recursive bool
+		want      map[string]string
+		wantErr   error
+	}{
+		{"/app", false, map[string]string{"/app/one": "1", "/app/two": "2"}, nil},
+		{"/app", true, map[string]string{"/app/one": "1", "/app/two": "2", "/app/sub/nested": "3"}, nil},
+		{"/app/one,single", false, map[string]string{"/app/one": "1", "single": "4"}, nil},
+		{"/missing", false, nil, ErrNameNotFound},
+	}
+	for storeName, ssmClient := range newTestStores() {
+		for _, tt := range tests {
+			t.Run(storeName+" "+tt.params, func(t *testing.T) {
+				result, err := ssmClient.GetParams(&tt.params, Flags{Recursive: tt.recursive, PrefixPath: true})
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Expected error %v but got %v", tt.wantErr, err)
+				}
+				if tt.wantErr == nil && !reflect.DeepEqual(result, tt.want) {
+					t.Errorf("Expected %s but got %s", tt.want, result)
+				}
+			})
+		}
+	}
+}
+
+func Test_StoreSaveAndDelete(t *testing.T) {
+	for storeName, ssmClient := range newTestStores() {
+		t.Run(storeName, func(t *testing.T) {
+			err := ssmClient.SaveParameters(map[string]string{"one": "new", "three": "added"}, nil, "/app", Flags{})
+			if err != nil {
+				t.Fatalf("Error in SaveParameters: %s", err)
+			}
+			types, err := ssmClient.GetParameterTypes([]string{"/app/one", "/app/three"})
+			if err != nil {
+				t.Fatalf("Error in GetParameterTypes: %s", err)
+			}
+			// an existing parameter keeps its type, a new one is a SecureString
+			wantTypes := map[string]string{"/app/one": "String", "/app/three": "SecureString"}
+			if !reflect.DeepEqual(types, wantTypes) {
+				t.Errorf("Expected types %s but got %s", wantTypes, types)
+			}
+
+			params := "/app"
+			names, err := ssmClient.ResolveParameterNames(&params, Flags{Recursive: true})
+			if err != nil {
+				t.Fatalf("Error in ResolveParameterNames: %s", err)
+			}
+			wantNames := []string{"/app/one", "/app/sub/nested", "/app/three", "/app/two"}
+			if !reflect.DeepEqual(names, wantNames) {
+				t.Errorf("Expected names %s but got %s", wantNames, names)
+			}
+
+			if err := ssmClient.DeleteParameters(names); err != nil {
+				t.Fatalf("Error in DeleteParameters: %s", err)
+			}
+			// a path without parameters is not found
+			if _, err := ssmClient.GetParams(&params, Flags{Recursive: true}); !errors.Is(err, ErrNameNotFound) {
+				t.Errorf("Expected ErrNameNotFound after deleting but got %v", err)
+			}
+		})
+	}
+}
+
+func Test_SaveParametersWithoutDecryption(t *testing.T) {
+	for _, flags := range []Flags{{Dry: true}, {}} {
+		memory := newMemorySSM()
+		memory.add("/app/secret", "old", "SecureString", "", "", nil)
+		ssmClient := NewSSM()
+		ssmClient.SSM = memory
+		if err := ssmClient.SaveParameters(map[string]string{"secret": "new"}, nil, "/app", flags); err != nil {
+			t.Fatalf("Error in SaveParameters: %s", err)
+		}
+		// the type of an existing SecureString is looked up without decrypting it
+		if memory.decryptCalls != 0 {
+			t.Errorf("Expected no decryption for %+v but got %d", flags, memory.decryptCalls)
+		}
+		if !flags.Dry && aws.StringValue(memory.params["/app/secret"].Type) != "SecureString" {
+			t.Errorf("Expected /app/secret to stay a SecureString but got %s", aws.StringValue(memory.params["/app/secret"].Type))
+		}
+	}
+}
+
+func Test_MemoryStorePut(t *testing.T) {
+	store := NewMemoryStore(map[string]string{"/app/one": "1"})
+	written, err := store.Put(ParameterDetails{Name: "/app/one", Value: "new"}, false)
+	if err != nil || written {
+		t.Errorf("Expected an existing parameter not to be overwritten, but got %t %v", written, err)
+	}
+	written, err = store.Put(ParameterDetails{Name: "/app/one", Value: "new", Type: "SecureString"}, true)
+	if err != nil || !written {
+		t.Errorf("Expected the parameter to be overwritten, but got %t %v", written, err)
+	}
+	params, _ := store.GetByNames([]string{"/app/one", "/app/missing"})
+	want := []Parameter{{Name: "/app/one", Value: "new", Type: "SecureString"}}
+	if !reflect.DeepEqual(params, want) {
+		t.Errorf("Expected %v but got %v", want, params)
+	}
+}
+
+func Test_StoreNotSupported(t *testing.T) {
+	ssmClient := NewSSMWithStore(NewMemoryStore(storeTestValues))
+	if _, err := ssmClient.GetParameterHistory("/app/one"); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("Expected ErrNotSupported for history but got %v", err)
+	}
+	if err := ssmClient.LabelParameters([]string{"/app/one"}, "prod"); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("Expected ErrNotSupported for labels but got %v", err)
+	}
+	params := "/app/one:prod"
+	if _, err := ssmClient.GetParams(&params, Flags{}); !errors.Is(err, ErrNameNotFound) {
+		t.Errorf("Expected ErrNameNotFound for a label but got %v", err)
+	}
+}
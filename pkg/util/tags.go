@@ -0,0 +1,89 @@
+package util
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/rs/zerolog/log"
+)
+
+// FindParameterNamesByTags returns the sorted names of all parameters which have all the given tags
+func (f *AWSSSM) FindParameterNamesByTags(tags map[string]string) ([]string, error) {
+	if err := f.requireSSM(); err != nil {
+		return nil, err
+	}
+	filters := make([]*ssm.ParameterStringFilter, 0, len(tags))
+	for _, key := range GetSortedNamesFromParams(tags) {
+		filters = append(filters, &ssm.ParameterStringFilter{
+			Key:    aws.String("tag:" + key),
+			Option: aws.String("Equals"),
+			Values: []*string{aws.String(tags[key])},
+		})
+	}
+
+	names := make([]string, 0)
+	var nextToken *string
+	for {
+		input := &ssm.DescribeParametersInput{
+			ParameterFilters: filters,
+			NextToken:        nextToken,
+		}
+		var output *ssm.DescribeParametersOutput
+		err := f.read("DescribeParameters", func() (err error) {
+			output, err = f.SSM.DescribeParameters(input)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, meta := range output.Parameters {
+			names = append(names, aws.StringValue(meta.Name))
+		}
+		nextToken = output.NextToken
+		if nextToken == nil {
+			break
+		}
+	}
+	sort.Strings(names)
+	log.Debug().Msgf("Names with tags %v: %v", tags, names)
+	return names, nil
+}
+
+// getParametersByTags reads the parameters with all tags of the flags.
+// If names or paths are given, only parameters with one of the names or below one of the paths are read.
+func (f *AWSSSM) getParametersByTags(params []string, flags Flags) (map[string]Parameter, error) {
+	names, err := f.FindParameterNamesByTags(flags.Tags)
+	if err != nil {
+		return nil, err
+	}
+	selected := make([]*string, 0, len(names))
+	for index := range names {
+		if len(params) == 0 || matchesParams(names[index], params, flags.Recursive) {
+			selected = append(selected, &names[index])
+		}
+	}
+	if len(selected) == 0 {
+		log.Error().Msgf("No names found for tags: %v", flags.Tags)
+		return nil, ErrNameNotFound
+	}
+	return f.GetParameters(selected, flags)
+}
+
+// matchesParams checks if a name is one of the names, or below one of the paths
+func matchesParams(name string, params []string, recursive bool) bool {
+	for _, param := range params {
+		if name == param {
+			return true
+		}
+		prefix := strings.TrimSuffix(param, "/") + "/"
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if recursive || !strings.Contains(strings.TrimPrefix(name, prefix), "/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newTagsTestSSM() (*AWSSSM, *memorySSM) {
+	memory := newMemorySSM()
+	memory.add("/app/db/password", "secret", "SecureString", "", "", map[string]string{"team": "payments", "svc": "api"})
+	memory.add("/app/db/user", "admin", "String", "", "", map[string]string{"team": "payments", "svc": "worker"})
+	memory.add("/app/url", "https://example.com", "String", "", "", map[string]string{"team": "payments", "svc": "api"})
+	memory.add("/other/url", "https://other.com", "String", "", "", map[string]string{"team": "search", "svc": "api"})
+	memory.add("plain", "value", "String", "", "", map[string]string{"team": "payments"})
+	ssmClient := NewSSM()
+	ssmClient.SSM = memory
+	return ssmClient, memory
+}
+
+func Test_GetParamsByTags(t *testing.T) {
+	tests := []struct {
+		name        string
+		paramstring string
+		flags       Flags
+		want        map[string]string
+		wantErr     bool
+	}{
+		{
+			name:        "one tag",
+			paramstring: "",
+			flags:       Flags{Tags: map[string]string{"team": "payments"}, PrefixPath: true},
+			want: map[string]string{
+				"/app/db/password": "secret",
+				"/app/db/user":     "admin",
+				"/app/url":         "https://example.com",
+				"plain":            "value",
+			},
+		},
+		{
+			name:        "all tags",
+			paramstring: "",
+			flags:       Flags{Tags: map[string]string{"team": "payments", "svc": "api"}, PrefixPath: true},
+			want:        map[string]string{"/app/db/password": "secret", "/app/url": "https://example.com"},
+		},
+		{
+			name:        "tags on path",
+			paramstring: "/app,/other",
+			flags:       Flags{Tags: map[string]string{"svc": "api"}, Recursive: true, Upper: true},
+			want:        map[string]string{"PASSWORD": "secret", "URL": "https://other.com"},
+		},
+		{
+			name:        "tags on path not recursive",
+			paramstring: "/app",
+			flags:       Flags{Tags: map[string]string{"team": "payments"}, PrefixPath: true},
+			want:        map[string]string{"/app/url": "https://example.com"},
+		},
+		{
+			name:        "tags on names",
+			paramstring: "plain,/app/db/user",
+			flags:       Flags{Tags: map[string]string{"team": "payments"}, PrefixPath: true},
+			want:        map[string]string{"/app/db/user": "admin", "plain": "value"},
+		},
+		{
+			name:        "no match",
+			paramstring: "",
+			flags:       Flags{Tags: map[string]string{"team": "unknown"}},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ssmClient, _ := newTagsTestSSM()
+			got, err := ssmClient.GetParams(&tt.paramstring, tt.flags)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %t but got %v", tt.wantErr, err)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v but got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_SaveParametersWithTags(t *testing.T) {
+	ssmClient, memory := newTagsTestSSM()
+	tags := map[string]string{"team": "payments", "svc": "billing"}
+	params := map[string]string{"url": "https://billing.example.com", "password": "new"}
+	err := ssmClient.SaveParameters(params, nil, "/app", Flags{Tags: tags})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if !reflect.DeepEqual(memory.tags["/app/password"], tags) {
+		t.Errorf("Expected tags %v on new parameter but got %v", tags, memory.tags["/app/password"])
+	}
+	if !reflect.DeepEqual(memory.tags["/app/url"], tags) {
+		t.Errorf("Expected tags %v on existing parameter but got %v", tags, memory.tags["/app/url"])
+	}
+}
@@ -0,0 +1,365 @@
+package util
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+)
+
+// memorySSM is a mock which keeps the parameters in memory, to test writing operations
+type memorySSM struct {
+	ssmiface.SSMAPI
+	params      map[string]*ssm.ParameterMetadata
+	values      map[string]string
+	tags        map[string]map[string]string
+	history     map[string][]*ssm.ParameterHistory
+	clock       time.Time
+	deleteCalls int
+	getCalls    int
+	// decryptCalls counts the reads with decryption, which need kms:Decrypt
+	decryptCalls int
+	// mutex guards the call counters for concurrent reads
+	mutex sync.Mutex
+}
+
+func newMemorySSM() *memorySSM {
+	return &memorySSM{
+		params:  make(map[string]*ssm.ParameterMetadata),
+		values:  make(map[string]string),
+		tags:    make(map[string]map[string]string),
+		history: make(map[string][]*ssm.ParameterHistory),
+		clock:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+// record adds the current version of a parameter to its history, each write is one hour after the last one
+func (m *memorySSM) record(name string) {
+	m.clock = m.clock.Add(time.Hour)
+	m.params[name].LastModifiedDate = aws.Time(m.clock)
+	m.history[name] = append(m.history[name], &ssm.ParameterHistory{
+		Name:             aws.String(name),
+		Value:            aws.String(m.values[name]),
+		Type:             m.params[name].Type,
+		KeyId:            m.params[name].KeyId,
+		Version:          m.params[name].Version,
+		LastModifiedDate: aws.Time(m.clock),
+		LastModifiedUser: aws.String("arn:aws:iam::111111111111:user/test"),
+	})
+}
+
+// add stores a parameter with its metadata and tags
+func (m *memorySSM) add(name string, value string, paramType string, keyId string, description string, tags map[string]string) {
+	m.params[name] = &ssm.ParameterMetadata{
+		Name:        aws.String(name),
+		Type:        aws.String(paramType),
+		Description: aws.String(description),
+		Tier:        aws.String(ssm.ParameterTierStandard),
+		Version:     aws.Int64(1),
+	}
+	if keyId != "" {
+		m.params[name].KeyId = aws.String(keyId)
+	}
+	m.values[name] = value
+	m.tags[name] = tags
+	m.record(name)
+}
+
+func (m *memorySSM) sortedNames() []string {
+	names := make([]string, 0, len(m.params))
+	for name := range m.params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (m *memorySSM) parameter(name string) *ssm.Parameter {
+	return &ssm.Parameter{
+		Name:    aws.String(name),
+		Value:   aws.String(m.values[name]),
+		Type:    m.params[name].Type,
+		Version: m.params[name].Version,
+	}
+}
+
+// namesOnPath returns the sorted names under a path
+func (m *memorySSM) namesOnPath(path string, recursive bool) []string {
+	prefix := strings.TrimSuffix(path, "/") + "/"
+	names := make([]string, 0)
+	for _, name := range m.sortedNames() {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if !recursive && strings.Contains(strings.TrimPrefix(name, prefix), "/") {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// namesWithTag returns the names which have the tag with the value
+func (m *memorySSM) namesWithTag(key string, value string, names []string) []string {
+	tagged := make([]string, 0)
+	for _, name := range names {
+		if tagValue, ok := m.tags[name][key]; ok && tagValue == value {
+			tagged = append(tagged, name)
+		}
+	}
+	return tagged
+}
+
+// page returns the names of one page of 10 names and the token of the next page
+func page(names []string, token *string) ([]string, *string) {
+	start := 0
+	if token != nil {
+		start, _ = strconv.Atoi(*token)
+	}
+	end := start + 10
+	if end >= len(names) {
+		return names[start:], nil
+	}
+	return names[start:end], aws.String(strconv.Itoa(end))
+}
+
+// selectParameter returns the parameter of a name with an optional :version or :label selector
+func (m *memorySSM) selectParameter(selected string) (*ssm.Parameter, bool) {
+	name, selector := SplitSelector(selected)
+	if _, ok := m.params[name]; !ok {
+		return nil, false
+	}
+	if selector == "" {
+		return m.parameter(name), true
+	}
+	for _, history := range m.history[name] {
+		if strconv.FormatInt(*history.Version, 10) == selector || containsString(history.Labels, selector) {
+			return &ssm.Parameter{
+				Name:     aws.String(name),
+				Value:    history.Value,
+				Type:     history.Type,
+				Version:  history.Version,
+				Selector: aws.String(":" + selector),
+			}, true
+		}
+	}
+	return nil, false
+}
+
+func containsString(values []*string, value string) bool {
+	for _, candidate := range values {
+		if *candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *memorySSM) GetParametersByPath(input *ssm.GetParametersByPathInput) (*ssm.GetParametersByPathOutput, error) {
+	output := new(ssm.GetParametersByPathOutput)
+	names := m.namesOnPath(*input.Path, aws.BoolValue(input.Recursive))
+	selector := ""
+	for _, filter := range input.ParameterFilters {
+		if *filter.Key == "Label" {
+			selector = ":" + *filter.Values[0]
+		}
+	}
+	selected := make([]string, 0, len(names))
+	for _, name := range names {
+		if _, ok := m.selectParameter(name + selector); ok {
+			selected = append(selected, name)
+		}
+	}
+	selected, next := page(selected, input.NextToken)
+	for _, name := range selected {
+		param, _ := m.selectParameter(name + selector)
+		output.Parameters = append(output.Parameters, param)
+	}
+	output.NextToken = next
+	return output, nil
+}
+
+func (m *memorySSM) GetParameter(input *ssm.GetParameterInput) (*ssm.GetParameterOutput, error) {
+	param, ok := m.selectParameter(*input.Name)
+	if !ok {
+		return nil, awserr.New(ssm.ErrCodeParameterNotFound, *input.Name, nil)
+	}
+	return &ssm.GetParameterOutput{Parameter: param}, nil
+}
+
+func (m *memorySSM) GetParameters(input *ssm.GetParametersInput) (*ssm.GetParametersOutput, error) {
+	m.mutex.Lock()
+	m.getCalls++
+	if aws.BoolValue(input.WithDecryption) {
+		m.decryptCalls++
+	}
+	m.mutex.Unlock()
+	output := new(ssm.GetParametersOutput)
+	for _, name := range input.Names {
+		if param, ok := m.selectParameter(*name); ok {
+			output.Parameters = append(output.Parameters, param)
+		} else {
+			output.InvalidParameters = append(output.InvalidParameters, name)
+		}
+	}
+	return output, nil
+}
+
+func (m *memorySSM) DescribeParameters(input *ssm.DescribeParametersInput) (*ssm.DescribeParametersOutput, error) {
+	output := new(ssm.DescribeParametersOutput)
+	names := m.sortedNames()
+	for _, filter := range input.ParameterFilters {
+		if *filter.Key == "Path" {
+			names = m.namesOnPath(*filter.Values[0], aws.StringValue(filter.Option) == "Recursive")
+		}
+		if strings.HasPrefix(*filter.Key, "tag:") {
+			names = m.namesWithTag(strings.TrimPrefix(*filter.Key, "tag:"), *filter.Values[0], names)
+		}
+	}
+	names, next := page(names, input.NextToken)
+	for _, name := range names {
+		output.Parameters = append(output.Parameters, m.params[name])
+	}
+	output.NextToken = next
+	return output, nil
+}
+
+func (m *memorySSM) ListTagsForResource(input *ssm.ListTagsForResourceInput) (*ssm.ListTagsForResourceOutput, error) {
+	output := new(ssm.ListTagsForResourceOutput)
+	for key, value := range m.tags[*input.ResourceId] {
+		output.TagList = append(output.TagList, &ssm.Tag{Key: aws.String(key), Value: aws.String(value)})
+	}
+	return output, nil
+}
+
+func (m *memorySSM) AddTagsToResource(input *ssm.AddTagsToResourceInput) (*ssm.AddTagsToResourceOutput, error) {
+	if m.tags[*input.ResourceId] == nil {
+		m.tags[*input.ResourceId] = make(map[string]string)
+	}
+	for _, tag := range input.Tags {
+		m.tags[*input.ResourceId][*tag.Key] = *tag.Value
+	}
+	return new(ssm.AddTagsToResourceOutput), nil
+}
+
+func (m *memorySSM) PutParameter(input *ssm.PutParameterInput) (*ssm.PutParameterOutput, error) {
+	version := int64(1)
+	if existing, ok := m.params[*input.Name]; ok {
+		if !aws.BoolValue(input.Overwrite) {
+			return nil, awserr.New(ssm.ErrCodeParameterAlreadyExists, *input.Name, nil)
+		}
+		version = *existing.Version + 1
+	}
+	m.params[*input.Name] = &ssm.ParameterMetadata{
+		Name:        input.Name,
+		Type:        input.Type,
+		KeyId:       input.KeyId,
+		Description: input.Description,
+		Tier:        input.Tier,
+		Version:     aws.Int64(version),
+	}
+	m.values[*input.Name] = *input.Value
+	m.record(*input.Name)
+	return &ssm.PutParameterOutput{Version: aws.Int64(version)}, nil
+}
+
+func (m *memorySSM) DeleteParameters(input *ssm.DeleteParametersInput) (*ssm.DeleteParametersOutput, error) {
+	m.deleteCalls++
+	output := new(ssm.DeleteParametersOutput)
+	for _, name := range input.Names {
+		if _, ok := m.params[*name]; !ok {
+			output.InvalidParameters = append(output.InvalidParameters, name)
+			continue
+		}
+		delete(m.params, *name)
+		delete(m.values, *name)
+		delete(m.tags, *name)
+		delete(m.history, *name)
+		output.DeletedParameters = append(output.DeletedParameters, name)
+	}
+	return output, nil
+}
+
+func (m *memorySSM) GetParameterHistory(input *ssm.GetParameterHistoryInput) (*ssm.GetParameterHistoryOutput, error) {
+	history, ok := m.history[*input.Name]
+	if !ok {
+		return nil, awserr.New(ssm.ErrCodeParameterNotFound, *input.Name, nil)
+	}
+	start := 0
+	if input.NextToken != nil {
+		start, _ = strconv.Atoi(*input.NextToken)
+	}
+	output := new(ssm.GetParameterHistoryOutput)
+	end := start + 2
+	if end >= len(history) {
+		end = len(history)
+	} else {
+		output.NextToken = aws.String(strconv.Itoa(end))
+	}
+	output.Parameters = history[start:end]
+	return output, nil
+}
+
+// LabelParameterVersion moves the labels to the version, the current version if none is given
+func (m *memorySSM) LabelParameterVersion(input *ssm.LabelParameterVersionInput) (*ssm.LabelParameterVersionOutput, error) {
+	history, ok := m.history[*input.Name]
+	if !ok {
+		return nil, awserr.New(ssm.ErrCodeParameterNotFound, *input.Name, nil)
+	}
+	target := history[len(history)-1]
+	if input.ParameterVersion != nil {
+		target = nil
+		for _, version := range history {
+			if *version.Version == *input.ParameterVersion {
+				target = version
+			}
+		}
+		if target == nil {
+			return nil, awserr.New(ssm.ErrCodeParameterVersionNotFound, *input.Name, nil)
+		}
+	}
+	for _, label := range input.Labels {
+		for _, version := range history {
+			labels := make([]*string, 0, len(version.Labels))
+			for _, existing := range version.Labels {
+				if *existing != *label {
+					labels = append(labels, existing)
+				}
+			}
+			version.Labels = labels
+		}
+		target.Labels = append(target.Labels, label)
+	}
+	return &ssm.LabelParameterVersionOutput{ParameterVersion: target.Version}, nil
+}
+
+func (m *memorySSM) UnlabelParameterVersion(input *ssm.UnlabelParameterVersionInput) (*ssm.UnlabelParameterVersionOutput, error) {
+	output := new(ssm.UnlabelParameterVersionOutput)
+	for _, version := range m.history[*input.Name] {
+		if *version.Version != *input.ParameterVersion {
+			continue
+		}
+		for _, label := range input.Labels {
+			if !containsString(version.Labels, *label) {
+				output.InvalidLabels = append(output.InvalidLabels, label)
+				continue
+			}
+			labels := make([]*string, 0, len(version.Labels))
+			for _, existing := range version.Labels {
+				if *existing != *label {
+					labels = append(labels, existing)
+				}
+			}
+			version.Labels = labels
+			output.RemovedLabels = append(output.RemovedLabels, label)
+		}
+		return output, nil
+	}
+	return nil, awserr.New(ssm.ErrCodeParameterVersionNotFound, *input.Name, nil)
+}
@@ -1,11 +1,15 @@
 package server
 
 import (
+	"fmt"
 	"github.com/gork74/aws-parameter-bulk/pkg/forms"
 	"github.com/gork74/aws-parameter-bulk/pkg/models"
 	"github.com/gork74/aws-parameter-bulk/pkg/util"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 func (app *application) home(w http.ResponseWriter, r *http.Request) {
@@ -77,26 +81,12 @@ func (app *application) postHome(w http.ResponseWriter, r *http.Request) {
 		recursiveRight = true
 	}
 	flagsLeft := util.Flags{
-		false,
-		jsonLeft,
-		false,
-		false,
-		false,
-		false,
-		recursiveLeft,
-		false,
-		false,
+		InJson:    jsonLeft,
+		Recursive: recursiveLeft,
 	}
 	flagsRight := util.Flags{
-		false,
-		jsonRight,
-		false,
-		false,
-		false,
-		false,
-		recursiveRight,
-		false,
-		false,
+		InJson:    jsonRight,
+		Recursive: recursiveRight,
 	}
 
 	app.logger.Debug().Msgf("Namesright: '%s'", namesRight)
@@ -117,7 +107,7 @@ func (app *application) postHome(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resultLeft, err := app.ssmClient.GetParams(&namesLeft, flagsLeft)
+	resultLeft, err := app.ssmClient.GetParamsDetailed(&namesLeft, flagsLeft)
 	if err != nil {
 		app.logger.Error().Msg(err.Error())
 		app.session.Put(r.Context(), "flasherror", "Error reading values from the left side input: "+err.Error())
@@ -125,71 +115,497 @@ func (app *application) postHome(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	sortedLeftNames := util.GetSortedNamesFromParams(resultLeft)
-
-	compares := make([]models.ValueCompare, 0)
-
-	for _, name := range sortedLeftNames {
-		value := resultLeft[name]
-		compare := models.ValueCompare{
-			LeftName:     name,
-			LeftOriginal: value,
-			LeftValue:    value,
-			Different:    false,
-		}
-		compares = append(compares, compare)
-	}
-
+	resultRight := make(map[string]util.Parameter)
 	if namesRight != "" {
-		resultRight, err := app.ssmClient.GetParams(&namesRight, flagsRight)
+		resultRight, err = app.ssmClient.GetParamsDetailed(&namesRight, flagsRight)
 		if err != nil {
 			app.logger.Error().Msg(err.Error())
 			app.session.Put(r.Context(), "flasherror", "Error reading values from the right side input: "+err.Error())
 			app.render(w, r, "error.page.tmpl", &templateData{})
 			return
 		}
+	}
 
-		sortedRightNames := util.GetSortedNamesFromParams(resultRight)
+	compares := compareParams(resultLeft, resultRight, namesRight != "")
 
-		index := 0
-		for _, name := range sortedRightNames {
-			value := resultRight[name]
-			compare := models.ValueCompare{
-				RightName:     name,
-				RightOriginal: value,
-				RightValue:    value,
-				Different:     false,
+	view := &templateData{
+		Form:           form,
+		NamesLeft:      namesLeft,
+		JsonLeft:       jsonLeft,
+		RecursiveLeft:  recursiveLeft,
+		NamesRight:     namesRight,
+		JsonRight:      jsonRight,
+		RecursiveRight: recursiveRight,
+		Compare:        compares,
+	}
+	view.Compare = updateCompares(view.Compare)
+	app.session.Put(r.Context(), "view", view)
+	app.render(w, r, "home.page.tmpl", view)
+	return
+}
+
+func (app *application) postSave(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		app.logger.Error().Msgf("Error parsing form: %s", err)
+		return
+	}
+
+	view, ok := app.loadedView(w, r)
+	if !ok {
+		return
+	}
+
+	form := forms.New(r.PostForm)
+	rows, err := selectedRows(form.Get("row"), len(view.Compare))
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		app.logger.Error().Msgf("Error reading row: %s", err)
+		return
+	}
+
+	// keep the edited values of all rows, even if only one row is saved
+	updateValuesFromForm(view, form)
+
+	changes := make([]models.ValueChange, 0)
+	for _, index := range rows {
+		compare := &view.Compare[index]
+		if !compare.LeftMissing && compare.LeftValue != compare.LeftOriginal {
+			if view.JsonLeft {
+				app.session.Put(r.Context(), "flasherror", "Values interpreted as JSON can not be saved: "+compare.LeftName)
+				break
 			}
-			if index < len(compares) {
-				compares[index].RightName = name
-				compares[index].RightOriginal = value
-				compares[index].RightValue = value
-			} else {
-				compares = append(compares, compare)
+			if compare.LeftSecret {
+				app.session.Put(r.Context(), "flasherror", "Secrets of Secrets Manager can not be saved: "+compare.LeftName)
+				break
+			}
+			change, err := app.saveValue(compare.LeftParameterName(), compare.LeftOriginal, compare.LeftValue)
+			if err != nil {
+				app.session.Put(r.Context(), "flasherror", "Error saving "+change.Name+": "+err.Error())
+				break
 			}
-			index++
+			compare.LeftOriginal = compare.LeftValue
+			changes = append(changes, change)
+		}
+		if !compare.RightMissing && compare.RightName != "" && compare.RightValue != compare.RightOriginal {
+			if view.JsonRight {
+				app.session.Put(r.Context(), "flasherror", "Values interpreted as JSON can not be saved: "+compare.RightName)
+				break
+			}
+			if compare.RightSecret {
+				app.session.Put(r.Context(), "flasherror", "Secrets of Secrets Manager can not be saved: "+compare.RightName)
+				break
+			}
+			change, err := app.saveValue(compare.RightParameterName(), compare.RightOriginal, compare.RightValue)
+			if err != nil {
+				app.session.Put(r.Context(), "flasherror", "Error saving "+change.Name+": "+err.Error())
+				break
+			}
+			compare.RightOriginal = compare.RightValue
+			changes = append(changes, change)
 		}
 	}
 
-	view := &templateData{
-		Form:       form,
-		NamesLeft:  namesLeft,
-		JsonLeft:   jsonLeft,
-		NamesRight: namesRight,
-		JsonRight:  jsonRight,
-		Compare:    compares,
+	if len(changes) > 0 {
+		app.session.Put(r.Context(), "flash", fmt.Sprintf("Saved %d value(s)", len(changes)))
+	} else {
+		app.session.Put(r.Context(), "flash", "No changed values to save")
 	}
+
+	view.Compare = updateCompares(view.Compare)
+	app.session.Put(r.Context(), "view", view)
+
+	// the saved changes are only shown once and not stored in the session
+	rendered := *view
+	rendered.Changes = changes
+	app.render(w, r, "home.page.tmpl", &rendered)
+}
+
+func (app *application) postCopy(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		app.logger.Error().Msgf("Error parsing form: %s", err)
+		return
+	}
+
+	view, ok := app.loadedView(w, r)
+	if !ok {
+		return
+	}
+
+	form := forms.New(r.PostForm)
+	// copy the values as they are currently edited, copying all rows selects the rows which differ after the edits
+	updateValuesFromForm(view, form)
+	view.Compare = updateCompares(view.Compare)
+	toLeft, rows, err := copyRows(form.Get("copy"), view.Compare)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		app.logger.Error().Msgf("Error reading copy: %s", err)
+		return
+	}
+
+	pending := make([]models.ValueCopy, 0)
+	skipped := make([]string, 0)
+	for _, index := range rows {
+		valueCopy, err := pendingCopy(view, index, toLeft)
+		if err != nil {
+			skipped = append(skipped, err.Error())
+			continue
+		}
+		pending = append(pending, valueCopy)
+	}
+	if len(skipped) > 0 {
+		app.session.Put(r.Context(), "flasherror", "Not copied: "+strings.Join(skipped, ", "))
+	}
+	if len(pending) == 0 {
+		app.session.Put(r.Context(), "flash", "No values to copy")
+	}
+
+	view.Pending = pending
 	view.Compare = updateCompares(view.Compare)
 	app.session.Put(r.Context(), "view", view)
 	app.render(w, r, "home.page.tmpl", view)
-	return
 }
 
+func (app *application) postCopyApply(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		app.logger.Error().Msgf("Error parsing form: %s", err)
+		return
+	}
+
+	view, ok := app.loadedView(w, r)
+	if !ok {
+		return
+	}
+
+	form := forms.New(r.PostForm)
+	if form.Get("apply") != "yes" {
+		view.Pending = nil
+		app.session.Put(r.Context(), "flash", "Copy cancelled")
+		app.session.Put(r.Context(), "view", view)
+		app.render(w, r, "home.page.tmpl", view)
+		return
+	}
+
+	changes := make([]models.ValueChange, 0)
+	for _, valueCopy := range view.Pending {
+		if valueCopy.Row >= len(view.Compare) {
+			continue
+		}
+		if targetSecret(view.Compare[valueCopy.Row], valueCopy.ToLeft) {
+			app.session.Put(r.Context(), "flasherror", "Secrets of Secrets Manager can not be written: "+valueCopy.Key)
+			break
+		}
+		change, err := app.saveValue(valueCopy.Name, valueCopy.Original, valueCopy.Value)
+		if err != nil {
+			app.session.Put(r.Context(), "flasherror", "Error saving "+change.Name+": "+err.Error())
+			break
+		}
+		compare := &view.Compare[valueCopy.Row]
+		if valueCopy.ToLeft {
+			compare.LeftName = valueCopy.Key
+			compare.LeftBasePath = valueCopy.BasePath
+			compare.LeftOriginal = valueCopy.Value
+			compare.LeftValue = valueCopy.Value
+			compare.LeftMissing = false
+		} else {
+			compare.RightName = valueCopy.Key
+			compare.RightBasePath = valueCopy.BasePath
+			compare.RightOriginal = valueCopy.Value
+			compare.RightValue = valueCopy.Value
+			compare.RightMissing = false
+		}
+		changes = append(changes, change)
+	}
+	app.session.Put(r.Context(), "flash", fmt.Sprintf("Copied %d value(s)", len(changes)))
+
+	view.Pending = nil
+	view.Compare = updateCompares(view.Compare)
+	app.session.Put(r.Context(), "view", view)
+
+	rendered := *view
+	rendered.Changes = changes
+	app.render(w, r, "home.page.tmpl", &rendered)
+}
+
+// history shows all versions of a parameter, the values only if values=1 is given
+func (app *application) history(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+	showValues := r.URL.Query().Get("values") == "1"
+
+	view := &templateData{HistoryName: name, ShowValues: showValues, History: make([]models.ParameterVersion, 0)}
+	versions, err := app.ssmClient.GetParameterHistory(name)
+	if err != nil {
+		app.logger.Error().Msgf("Error reading history of %s: %s", name, err)
+		app.session.Put(r.Context(), "flasherror", "Error reading history of "+name+": "+err.Error())
+		app.render(w, r, "history.page.tmpl", view)
+		return
+	}
+	// newest version first
+	for index := len(versions) - 1; index >= 0; index-- {
+		version := versions[index]
+		value := version.Value
+		if !showValues {
+			value = util.MaskValue(value)
+		}
+		view.History = append(view.History, models.ParameterVersion{
+			Version:  version.Version,
+			Modified: version.LastModifiedDate.Format(time.RFC3339),
+			User:     version.LastModifiedUser,
+			Labels:   strings.Join(version.Labels, ", "),
+			Value:    value,
+		})
+	}
+	app.render(w, r, "history.page.tmpl", view)
+}
+
+// loadedView returns the view from the session, or renders the home page with an error if nothing was loaded yet
+func (app *application) loadedView(w http.ResponseWriter, r *http.Request) (*templateData, bool) {
+	view, ok := app.session.Get(r.Context(), "view").(*templateData)
+	if !ok || len(view.Compare) == 0 {
+		app.session.Put(r.Context(), "flasherror", "Nothing loaded, load values first")
+		app.render(w, r, "home.page.tmpl", &templateData{Form: forms.New(nil), Compare: make([]models.ValueCompare, 0)})
+		return nil, false
+	}
+	return view, true
+}
+
+// updateValuesFromForm sets the edited values of all rows
+func updateValuesFromForm(view *templateData, form *forms.Form) {
+	for index := range view.Compare {
+		compare := &view.Compare[index]
+		if values, ok := form.Values[fmt.Sprintf("leftvalue%d", index)]; ok && !compare.LeftMissing {
+			compare.LeftValue = normalizeNewlines(values[0], compare.LeftOriginal)
+		}
+		if values, ok := form.Values[fmt.Sprintf("rightvalue%d", index)]; ok && !compare.RightMissing {
+			compare.RightValue = normalizeNewlines(values[0], compare.RightOriginal)
+		}
+	}
+}
+
+// copyRows reads the direction and rows of a copy in the form "right3", "left3", "right-all" or "left-all".
+// Copying all rows only selects the rows which are different.
+func copyRows(value string, compares []models.ValueCompare) (bool, []int, error) {
+	toLeft := strings.HasPrefix(value, "left")
+	if !toLeft && !strings.HasPrefix(value, "right") {
+		return false, nil, fmt.Errorf("invalid copy direction: %s", value)
+	}
+	row := strings.TrimPrefix(strings.TrimPrefix(value, "left"), "right")
+	if row != "-all" {
+		rows, err := selectedRows(row, len(compares))
+		return toLeft, rows, err
+	}
+	rows := make([]int, 0)
+	for index, compare := range compares {
+		if compare.Different {
+			rows = append(rows, index)
+		}
+	}
+	return toLeft, rows, nil
+}
+
+// pendingCopy prepares the write of one row to the other side.
+// If the target is missing, the source name is created on the base path shared by all values of the target side.
+func pendingCopy(view *templateData, index int, toLeft bool) (models.ValueCopy, error) {
+	compare := view.Compare[index]
+	source := compare.LeftName
+	sourceMissing := compare.LeftMissing
+	valueCopy := models.ValueCopy{
+		ValueChange: models.ValueChange{Original: compare.RightOriginal, Value: compare.LeftValue},
+		Row:         index,
+		ToLeft:      toLeft,
+		Key:         compare.RightName,
+		BasePath:    compare.RightBasePath,
+	}
+	targetMissing := compare.RightMissing
+	targetJson := view.JsonRight
+	if toLeft {
+		source = compare.RightName
+		sourceMissing = compare.RightMissing || compare.RightName == ""
+		valueCopy.Original = compare.LeftOriginal
+		valueCopy.Value = compare.RightValue
+		valueCopy.Key = compare.LeftName
+		valueCopy.BasePath = compare.LeftBasePath
+		targetMissing = compare.LeftMissing
+		targetJson = view.JsonLeft
+	}
+
+	if sourceMissing {
+		return valueCopy, fmt.Errorf("%s has no value to copy", valueCopy.Key)
+	}
+	if targetJson {
+		return valueCopy, fmt.Errorf("%s is interpreted as JSON", valueCopy.Key)
+	}
+	if targetSecret(compare, toLeft) {
+		return valueCopy, fmt.Errorf("%s is a secret of Secrets Manager", valueCopy.Key)
+	}
+	if valueCopy.Key == "" && !targetMissing {
+		return valueCopy, fmt.Errorf("%s has nothing loaded to copy to", source)
+	}
+	if targetMissing {
+		basePath, ok := uniqueBasePath(view.Compare, toLeft)
+		if !ok {
+			return valueCopy, fmt.Errorf("%s has no unique path to create it on", source)
+		}
+		valueCopy.Key = source
+		valueCopy.BasePath = basePath
+		valueCopy.Original = ""
+	}
+	valueCopy.Name = models.JoinBasePath(valueCopy.BasePath, valueCopy.Key)
+	return valueCopy, nil
+}
+
+// targetSecret returns true if the value a copy writes to is a secret of Secrets Manager, which is read only
+func targetSecret(compare models.ValueCompare, toLeft bool) bool {
+	if toLeft {
+		return compare.LeftSecret
+	}
+	return compare.RightSecret
+}
+
+// uniqueBasePath returns the base path of one side, if all loaded values on this side share it.
+// Secrets are left out, new values are created as ssm parameters.
+func uniqueBasePath(compares []models.ValueCompare, left bool) (string, bool) {
+	basePaths := make(map[string]bool)
+	for _, compare := range compares {
+		if left && !compare.LeftMissing && !compare.LeftSecret {
+			basePaths[compare.LeftBasePath] = true
+		}
+		if !left && !compare.RightMissing && compare.RightName != "" && !compare.RightSecret {
+			basePaths[compare.RightBasePath] = true
+		}
+	}
+	if len(basePaths) != 1 {
+		return "", false
+	}
+	for basePath := range basePaths {
+		return basePath, true
+	}
+	return "", false
+}
+
+func (app *application) saveValue(name string, original string, value string) (models.ValueChange, error) {
+	change := models.ValueChange{
+		Name:     name,
+		Original: original,
+		Value:    value,
+	}
+	app.logger.Info().Msgf("Saving %s", name)
+	err := app.ssmClient.SaveParameter(name, value, "")
+	if err != nil {
+		app.logger.Error().Msg(err.Error())
+	}
+	return change, err
+}
+
+// selectedRows returns the indexes of the rows to save, either a single row or all rows
+func selectedRows(row string, count int) ([]int, error) {
+	rows := make([]int, 0)
+	if row == "all" {
+		for index := 0; index < count; index++ {
+			rows = append(rows, index)
+		}
+		return rows, nil
+	}
+	index, err := strconv.Atoi(row)
+	if err != nil {
+		return rows, err
+	}
+	if index < 0 || index >= count {
+		return rows, fmt.Errorf("row %d out of range", index)
+	}
+	return append(rows, index), nil
+}
+
+// normalizeNewlines reverts the CRLF line endings, which browsers send for textareas, if the original value does not use them
+func normalizeNewlines(value string, original string) string {
+	if strings.Contains(original, "\r\n") {
+		return value
+	}
+	return strings.ReplaceAll(value, "\r\n", "\n")
+}
+
+// compareParams pairs the rows of both sides by their normalized name.
+// Names which only exist on one side are marked as missing on the other side, if the other side was loaded.
+func compareParams(left map[string]util.Parameter, right map[string]util.Parameter, withRight bool) []models.ValueCompare {
+	leftNames := groupByNormalizedName(left)
+	rightNames := groupByNormalizedName(right)
+
+	keys := make([]string, 0, len(leftNames)+len(rightNames))
+	for key := range leftNames {
+		keys = append(keys, key)
+	}
+	for key := range rightNames {
+		if _, ok := leftNames[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	compares := make([]models.ValueCompare, 0)
+	for _, key := range keys {
+		// names which normalize to the same key on one side are paired in sorted order
+		rows := len(leftNames[key])
+		if len(rightNames[key]) > rows {
+			rows = len(rightNames[key])
+		}
+		for index := 0; index < rows; index++ {
+			compare := models.ValueCompare{}
+			if index < len(leftNames[key]) {
+				name := leftNames[key][index]
+				compare.LeftName = name
+				compare.LeftOriginal = left[name].Value
+				compare.LeftValue = left[name].Value
+				compare.LeftBasePath = models.BasePath(left[name].Name)
+				compare.LeftSecret = left[name].SecretsManager
+			} else {
+				compare.LeftMissing = true
+			}
+			if index < len(rightNames[key]) {
+				name := rightNames[key][index]
+				compare.RightName = name
+				compare.RightOriginal = right[name].Value
+				compare.RightValue = right[name].Value
+				compare.RightBasePath = models.BasePath(right[name].Name)
+				compare.RightSecret = right[name].SecretsManager
+			} else if withRight {
+				compare.RightMissing = true
+			}
+			compares = append(compares, compare)
+		}
+	}
+	return compares
+}
+
+// groupByNormalizedName returns the sorted names of the params for each normalized name
+func groupByNormalizedName(params map[string]util.Parameter) map[string][]string {
+	groups := make(map[string][]string)
+	for _, name := range util.GetSortedNamesFromParams(util.GetValuesFromParams(params)) {
+		key := normalizeName(name)
+		groups[key] = append(groups[key], name)
+	}
+	return groups
+}
+
+// normalizeName makes names comparable across sources with a different case or separator, e.g. db-host and DB_HOST
+func normalizeName(name string) string {
+	return strings.NewReplacer("-", "_", ".", "_").Replace(strings.ToUpper(name))
+}
+
+// updateCompares marks the rows whose current values differ, including values edited in the form
 func updateCompares(compares []models.ValueCompare) []models.ValueCompare {
 	result := make([]models.ValueCompare, 0)
 	for _, originalCompare := range compares {
 		compare := originalCompare
-		compare.Different = compare.RightOriginal != compare.LeftOriginal
+		compare.Different = compare.LeftMissing || compare.RightMissing || compare.RightValue != compare.LeftValue
 		result = append(result, compare)
 	}
 	return result
@@ -2,6 +2,7 @@
 
 import (
 	"bytes"
+	"github.com/gork74/aws-parameter-bulk/pkg/util"
 	"net/http"
 	"net/url"
 	"testing"
@@ -62,6 +63,7 @@ func Test_application_postHome(t *testing.T) {
 		{"Valid namesleft", "Three1", "", false, true, csrfToken, http.StatusOK, []byte(">ThreeVal1<")},
 		{"Empty namesleft but namesright", "", "One1", false, true, csrfToken, http.StatusOK, []byte("Names not valid")},
 		{"Valid namesleft and namesright", "One1", "One2", false, true, csrfToken, http.StatusOK, []byte(">OneVal2<")},
+		{"Name missing on the right", "/path2", "One2", false, true, csrfToken, http.StatusOK, []byte(">missing<")},
 		{"Invalid CSRF Token", "One1", "", false, true, "wrongToken", http.StatusBadRequest, nil},
 	}
 
@@ -85,6 +87,329 @@ func Test_application_postHome(t *testing.T) {
 	}
 }
 
+func Test_application_postHomeRecursive(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	_, _, body := ts.get(t, "/", true)
+	csrfToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name           string
+		recursiveleft  bool
+		recursiveright bool
+		wantBody       []byte
+		wantNotBody    []byte
+	}{
+		{"Only left", true, false, []byte(`id="recursiveleft" checked`), []byte(`id="recursiveright" checked`)},
+		{"Only right", false, true, []byte(`id="recursiveright" checked`), []byte(`id="recursiveleft" checked`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("namesleft", "/path")
+			form.Add("namesright", "/path2")
+			if tt.recursiveleft {
+				form.Add("recursiveleft", "on")
+			}
+			if tt.recursiveright {
+				form.Add("recursiveright", "on")
+			}
+			form.Add("csrf_token", csrfToken)
+
+			_, _, body := ts.postForm(t, "/", form, true)
+
+			if !bytes.Contains(body, tt.wantBody) {
+				t.Errorf("want body %s to contain %q", body, tt.wantBody)
+			}
+			if bytes.Contains(body, tt.wantNotBody) {
+				t.Errorf("want body %s not to contain %q", body, tt.wantNotBody)
+			}
+		})
+	}
+}
+
+func Test_compareParams(t *testing.T) {
+	left := map[string]util.Parameter{
+		"One1":    {Name: "/dev/One1", Value: "OneVal1"},
+		"One2":    {Name: "/dev/One2", Value: "OneVal2"},
+		"db-host": {Name: "/dev/db-host", Value: "localhost"},
+	}
+	right := map[string]util.Parameter{
+		"One2":    {Name: "/prod/One2", Value: "OneVal2"},
+		"Three1":  {Name: "/prod/Three1", Value: "ThreeVal1"},
+		"DB_HOST": {Name: "/prod/DB_HOST", Value: "db"},
+	}
+
+	tests := []struct {
+		leftName     string
+		rightName    string
+		leftMissing  bool
+		rightMissing bool
+		different    bool
+	}{
+		{"db-host", "DB_HOST", false, false, true},
+		{"One1", "", false, true, true},
+		{"One2", "One2", false, false, false},
+		{"", "Three1", true, false, true},
+	}
+
+	compares := updateCompares(compareParams(left, right, true))
+	if len(compares) != len(tests) {
+		t.Fatalf("want %d rows; got %d", len(tests), len(compares))
+	}
+	for index, tt := range tests {
+		t.Run(tt.leftName+"/"+tt.rightName, func(t *testing.T) {
+			compare := compares[index]
+			if compare.LeftName != tt.leftName || compare.RightName != tt.rightName {
+				t.Errorf("want %q/%q; got %q/%q", tt.leftName, tt.rightName, compare.LeftName, compare.RightName)
+			}
+			if compare.LeftMissing != tt.leftMissing || compare.RightMissing != tt.rightMissing {
+				t.Errorf("want missing %t/%t; got %t/%t", tt.leftMissing, tt.rightMissing, compare.LeftMissing, compare.RightMissing)
+			}
+			if compare.Different != tt.different {
+				t.Errorf("want different %t; got %t", tt.different, compare.Different)
+			}
+			if !tt.leftMissing && compare.LeftParameterName() != "/dev/"+tt.leftName {
+				t.Errorf("want left parameter /dev/%s; got %s", tt.leftName, compare.LeftParameterName())
+			}
+		})
+	}
+}
+
+func Test_application_postSave(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	_, _, body := ts.get(t, "/", true)
+	csrfToken := extractCSRFToken(t, body)
+
+	// load the values to edit into the session
+	form := url.Values{}
+	form.Add("namesleft", "One1")
+	form.Add("namesright", "One2")
+	form.Add("csrf_token", csrfToken)
+	ts.postForm(t, "/", form, false)
+
+	tests := []struct {
+		name      string
+		row       string
+		leftValue string
+		csrfToken string
+		wantCode  int
+		wantBody  []byte
+	}{
+		{"Unchanged row", "0", "OneVal1", csrfToken, http.StatusOK, []byte("No changed values to save")},
+		{"Changed row", "0", "ChangedVal1", csrfToken, http.StatusOK, []byte("<td>OneVal1</td><td>ChangedVal1</td>")},
+		{"Saved value is the new original", "all", "ChangedVal1", csrfToken, http.StatusOK, []byte("No changed values to save")},
+		{"Row out of range", "5", "OneVal1", csrfToken, http.StatusBadRequest, nil},
+		{"Invalid CSRF Token", "0", "OneVal1", "wrongToken", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("row", tt.row)
+			form.Add("leftvalue0", tt.leftValue)
+			form.Add("csrf_token", tt.csrfToken)
+
+			code, _, body := ts.postForm(t, "/save", form, tt.wantBody != nil)
+
+			if code != tt.wantCode {
+				t.Errorf("want %d; got %d", tt.wantCode, code)
+			}
+
+			if tt.wantBody != nil && !bytes.Contains(body, tt.wantBody) {
+				t.Errorf("want body %s to contain %q", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func Test_application_postCopy(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	_, _, body := ts.get(t, "/", true)
+	csrfToken := extractCSRFToken(t, body)
+
+	// One1 is only loaded on the left side
+	form := url.Values{}
+	form.Add("namesleft", "/path2")
+	form.Add("namesright", "One2")
+	form.Add("csrf_token", csrfToken)
+	ts.postForm(t, "/", form, false)
+
+	tests := []struct {
+		name     string
+		path     string
+		field    string
+		value    string
+		wantCode int
+		wantBody []byte
+	}{
+		{"Copy from missing side", "/copy", "copy", "left0", http.StatusOK, []byte("No values to copy")},
+		{"Preview missing right", "/copy", "copy", "right0", http.StatusOK, []byte("<td>One1</td><td></td><td>OneVal1</td>")},
+		{"Cancel", "/copy/apply", "apply", "no", http.StatusOK, []byte("Copy cancelled")},
+		{"Preview sync all", "/copy", "copy", "right-all", http.StatusOK, []byte("Apply 1 write(s)")},
+		{"Apply", "/copy/apply", "apply", "yes", http.StatusOK, []byte("Copied 1 value(s)")},
+		{"Nothing left to sync", "/copy", "copy", "right-all", http.StatusOK, []byte("No values to copy")},
+		{"Invalid direction", "/copy", "copy", "up0", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add(tt.field, tt.value)
+			form.Add("csrf_token", csrfToken)
+
+			code, _, body := ts.postForm(t, tt.path, form, tt.wantBody != nil)
+
+			if code != tt.wantCode {
+				t.Errorf("want %d; got %d", tt.wantCode, code)
+			}
+
+			if tt.wantBody != nil && !bytes.Contains(body, tt.wantBody) {
+				t.Errorf("want body %s to contain %q", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func Test_application_postCopyEdited(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	_, _, body := ts.get(t, "/", true)
+	csrfToken := extractCSRFToken(t, body)
+
+	// One1 is only loaded on the left side, One2 is the same on both sides
+	form := url.Values{}
+	form.Add("namesleft", "/path2")
+	form.Add("namesright", "One2")
+	form.Add("csrf_token", csrfToken)
+	ts.postForm(t, "/", form, false)
+
+	// the edit of One2 makes it differ, so syncing all rows copies it as well
+	form = url.Values{}
+	form.Add("copy", "right-all")
+	form.Add("leftvalue1", "EditedVal2")
+	form.Add("csrf_token", csrfToken)
+	code, _, body := ts.postForm(t, "/copy", form, true)
+
+	if code != http.StatusOK {
+		t.Errorf("want %d; got %d", http.StatusOK, code)
+	}
+	for _, want := range [][]byte{[]byte("Apply 2 write(s)"), []byte("<td>One2</td><td>OneVal2</td><td>EditedVal2</td>")} {
+		if !bytes.Contains(body, want) {
+			t.Errorf("want body %s to contain %q", body, want)
+		}
+	}
+}
+
+func Test_application_secrets(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	_, _, body := ts.get(t, "/", true)
+	csrfToken := extractCSRFToken(t, body)
+
+	// the secret One2 on the left is compared to the parameter One2 on the right
+	form := url.Values{}
+	form.Add("namesleft", "sm://prod/One2")
+	form.Add("namesright", "One2")
+	form.Add("csrf_token", csrfToken)
+	_, _, body = ts.postForm(t, "/", form, true)
+	if !bytes.Contains(body, []byte(`<span class="badge badge-info">secret</span>`)) || bytes.Contains(body, []byte("/history?name=prod")) {
+		t.Errorf("want body %s to show the secret without history", body)
+	}
+
+	tests := []struct {
+		name      string
+		path      string
+		field     string
+		value     string
+		leftValue string
+		wantBody  []byte
+	}{
+		{"Copy to the secret", "/copy", "copy", "left0", "", []byte("Not copied: One2 is a secret of Secrets Manager")},
+		{"Copy from the secret", "/copy", "copy", "right0", "", []byte("<td>One2</td><td>OneVal2</td><td>SecretVal2</td>")},
+		{"Cancel", "/copy/apply", "apply", "no", "", []byte("Copy cancelled")},
+		{"Save the secret", "/save", "row", "0", "EditedVal2", []byte("Secrets of Secrets Manager can not be saved: One2")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add(tt.field, tt.value)
+			if tt.leftValue != "" {
+				form.Add("leftvalue0", tt.leftValue)
+			}
+			form.Add("csrf_token", csrfToken)
+
+			code, _, body := ts.postForm(t, tt.path, form, true)
+
+			if code != http.StatusOK {
+				t.Errorf("want %d; got %d", http.StatusOK, code)
+			}
+			if !bytes.Contains(body, tt.wantBody) {
+				t.Errorf("want body %s to contain %q", body, tt.wantBody)
+			}
+		})
+	}
+
+	if writes := app.ssmClient.SecretsManager.(*MockSecretsManager).writes; writes != 0 {
+		t.Errorf("want no writes to Secrets Manager; got %d", writes)
+	}
+	if stray, _ := app.ssmClient.Store.GetByNames([]string{"One2", "prod/One2"}); len(stray) != 1 || stray[0].Value != "OneVal2" {
+		t.Errorf("want only the unchanged parameter One2; got %v", stray)
+	}
+}
+
+func Test_application_history(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name        string
+		urlPath     string
+		wantCode    int
+		wantBody    []byte
+		notWantBody []byte
+	}{
+		{"Masked", "/history?name=One1", http.StatusOK,
+			[]byte("<td>2</td><td>2024-01-01T01:00:00Z</td><td>user/new</td><td>prod</td><td><pre class=\"mb-0\">****</pre></td>"),
+			[]byte("OldVal1")},
+		{"Values", "/history?name=One1&values=1", http.StatusOK,
+			[]byte("<td>1</td><td>2024-01-01T00:00:00Z</td><td>user/old</td><td></td><td><pre class=\"mb-0\">OldVal1</pre></td>"),
+			nil},
+		{"Missing name", "/history", http.StatusBadRequest, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath, tt.wantBody != nil)
+
+			if code != tt.wantCode {
+				t.Errorf("want %d; got %d", tt.wantCode, code)
+			}
+			if tt.wantBody != nil && !bytes.Contains(body, tt.wantBody) {
+				t.Errorf("want body %s to contain %q", body, tt.wantBody)
+			}
+			if tt.notWantBody != nil && bytes.Contains(body, tt.notWantBody) {
+				t.Errorf("want body %s not to contain %q", body, tt.notWantBody)
+			}
+		})
+	}
+}
+
 func Test_application_postReset(t *testing.T) {
 	app := newTestApplication(t)
 	ts := newTestServer(t, app.routes())
@@ -46,6 +46,10 @@ func (app *application) routes() http.Handler {
 	mux.Get("/", dynamicMiddleware.ThenFunc(app.home))
 	mux.Post("/", dynamicMiddleware.ThenFunc(app.postHome))
 	mux.Post("/reset", dynamicMiddleware.ThenFunc(app.postReset))
+	mux.Post("/save", dynamicMiddleware.ThenFunc(app.postSave))
+	mux.Post("/copy", dynamicMiddleware.ThenFunc(app.postCopy))
+	mux.Post("/copy/apply", dynamicMiddleware.ThenFunc(app.postCopyApply))
+	mux.Get("/history", dynamicMiddleware.ThenFunc(app.history))
 	mux.Get("/static/", http.StripPrefix("/static", fileServer))
 
 	return standardMiddleware.Then(mux)
@@ -20,7 +20,7 @@ type application struct {
 	ssmClient     *util.AWSSSM
 }
 
-func ListenAndServe(logger *zerolog.Logger, address string) {
+func ListenAndServe(logger *zerolog.Logger, address string, ssmClient *util.AWSSSM) {
 
 	var err error
 
@@ -33,7 +33,6 @@ func ListenAndServe(logger *zerolog.Logger, address string) {
 		logger.Fatal().Msgf("Template cache Error %s", err)
 	}
 
-	ssmClient := util.NewSSM()
 	app := &application{logger, session, templateCache, ssmClient}
 
 	srv := &http.Server{
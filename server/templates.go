@@ -28,6 +28,11 @@ type templateData struct {
 	FlashError     string
 	Form           *forms.Form
 	Compare        []models.ValueCompare
+	Changes        []models.ValueChange
+	Pending        []models.ValueCopy
+	HistoryName    string
+	History        []models.ParameterVersion
+	ShowValues     bool
 }
 
 // Initialize a template.FuncMap object and store it in a global variable. This is
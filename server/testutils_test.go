@@ -1,7 +1,6 @@
 package server
 
 import (
-	"fmt"
 	"html"
 	"io/ioutil"
 	"net/http"
@@ -16,83 +15,55 @@
 
 	"github.com/alexedwards/scs/v2"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
 	"github.com/aws/aws-sdk-go/service/ssm"
 	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
 	"github.com/gork74/aws-parameter-bulk/pkg/util"
-	"github.com/rs/zerolog/log"
 
 	"github.com/gork74/aws-parameter-bulk/conf"
 	"github.com/rs/zerolog"
 )
 
+// MockSSM only answers the history, the parameters are read from a util.MemoryStore
 type MockSSM struct {
 	ssmiface.SSMAPI
 	err error
 }
 
-func nameString(parameter ssm.GetParametersInput) string {
-	result := "["
-	for i, param := range parameter.Names {
-		if i > 0 {
-			result += ", "
-		}
-		result += fmt.Sprintf("%s", *param)
-	}
-	return result + "]"
-}
-
-func (sp *MockSSM) GetParameter(input *ssm.GetParameterInput) (*ssm.GetParameterOutput, error) {
-	output := new(ssm.GetParameterOutput)
-	log.Info().Msgf("%s", *input.Name)
+func (sp *MockSSM) GetParameterHistory(input *ssm.GetParameterHistoryInput) (*ssm.GetParameterHistoryOutput, error) {
+	output := new(ssm.GetParameterHistoryOutput)
 	if *input.Name == "One1" {
-		name1 := "One1"
-		output.Parameter = &ssm.Parameter{Name: &name1, Value: aws.String("OneVal1")}
+		modified := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		output.Parameters = append(output.Parameters,
+			&ssm.ParameterHistory{Name: input.Name, Value: aws.String("OldVal1"), Version: aws.Int64(1),
+				LastModifiedDate: aws.Time(modified), LastModifiedUser: aws.String("user/old")},
+			&ssm.ParameterHistory{Name: input.Name, Value: aws.String("OneVal1"), Version: aws.Int64(2),
+				LastModifiedDate: aws.Time(modified.Add(time.Hour)), LastModifiedUser: aws.String("user/new"),
+				Labels: aws.StringSlice([]string{"prod"})})
 	}
 	return output, sp.err
 }
 
-func (sp *MockSSM) GetParameters(input *ssm.GetParametersInput) (*ssm.GetParametersOutput, error) {
-	output := new(ssm.GetParametersOutput)
-	log.Info().Msgf("%s", nameString(*input))
-	if nameString(*input) == "[One1]" {
-		name1 := "One1"
-		output.Parameters = append(output.Parameters, &ssm.Parameter{Name: &name1, Value: aws.String("OneVal1")})
-	}
-	if nameString(*input) == "[Three1]" {
-		name1 := "Three1"
-		output.Parameters = append(output.Parameters, &ssm.Parameter{Name: &name1, Value: aws.String("ThreeVal1")})
-	}
-	if nameString(*input) == "[One2]" {
-		name1 := "One2"
-		output.Parameters = append(output.Parameters, &ssm.Parameter{Name: &name1, Value: aws.String("OneVal2")})
-	}
-	if nameString(*input) == "[One1, One2]" {
-		name1 := "One1"
-		name2 := "One2"
-		output.Parameters = append(output.Parameters, &ssm.Parameter{Name: &name1, Value: aws.String("OneVal1")})
-		output.Parameters = append(output.Parameters, &ssm.Parameter{Name: &name2, Value: aws.String("OneVal2")})
-	}
-	if nameString(*input) == "[Three1, Three2]" {
-		name1 := "Three1"
-		name2 := "Three2"
-		output.Parameters = append(output.Parameters, &ssm.Parameter{Name: &name1, Value: aws.String("ThreeVal1")})
-		output.Parameters = append(output.Parameters, &ssm.Parameter{Name: &name2, Value: aws.String("ThreeVal2")})
-	}
-	return output, sp.err
+// MockSecretsManager answers single reads of its secrets and counts the writes
+type MockSecretsManager struct {
+	secretsmanageriface.SecretsManagerAPI
+	secrets map[string]string
+	writes  int
 }
 
-func (sp *MockSSM) GetParametersByPath(input *ssm.GetParametersByPathInput) (*ssm.GetParametersByPathOutput, error) {
-	output := new(ssm.GetParametersByPathOutput)
-	params := make([]*ssm.Parameter, 0)
-	if *input.Path == "/path" {
-		params = append(params, &ssm.Parameter{Name: aws.String("One1"), Value: aws.String("OneVal1")})
+func (sm *MockSecretsManager) GetSecretValue(input *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
+	value, ok := sm.secrets[*input.SecretId]
+	if !ok {
+		return nil, awserr.New(secretsmanager.ErrCodeResourceNotFoundException, *input.SecretId, nil)
 	}
-	if *input.Path == "/path2" {
-		params = append(params, &ssm.Parameter{Name: aws.String("One1"), Value: aws.String("OneVal1")})
-		params = append(params, &ssm.Parameter{Name: aws.String("One2"), Value: aws.String("OneVal2")})
-	}
-	output.Parameters = params
-	return output, sp.err
+	return &secretsmanager.GetSecretValueOutput{Name: input.SecretId, SecretString: aws.String(value)}, nil
+}
+
+func (sm *MockSecretsManager) PutSecretValue(input *secretsmanager.PutSecretValueInput) (*secretsmanager.PutSecretValueOutput, error) {
+	sm.writes++
+	return &secretsmanager.PutSecretValueOutput{}, nil
 }
 
 // Define a custom testServer type which anonymously embeds a httptest.Server
@@ -137,10 +108,21 @@ func newTestApplication(t *testing.T) *application {
 	session := scs.New()
 	session.Lifetime = 24 * 30 * time.Hour
 
-	ssmClient := util.NewSSM()
+	ssmClient := util.NewSSMWithStore(util.NewMemoryStore(map[string]string{
+		"One1":        "OneVal1",
+		"One2":        "OneVal2",
+		"Three1":      "ThreeVal1",
+		"Three2":      "ThreeVal2",
+		"/path/One1":  "OneVal1",
+		"/path2/One1": "OneVal1",
+		"/path2/One2": "OneVal2",
+	}))
 	ssmClient.SSM = &MockSSM{
 		err: nil, //errors.New("my custom error"),
 	}
+	ssmClient.SecretsManager = &MockSecretsManager{
+		secrets: map[string]string{"prod/One2": "SecretVal2"},
+	}
 	// Initialize the dependencies, using the mocks for the loggers and
 	// the client.
 	app := &application{